@@ -13,11 +13,16 @@ env GOPATH="$PWD/src" \
     GIN_MODE="${ENV_VARS['GIN_MODE']}" \
     DB_URL="${ENV_VARS['DB_URL']}" \
     APP_MAX_SIGNIN_TRIES="${ENV_VARS['APP_MAX_SIGNIN_TRIES']}" \
+    APP_POEM_REACTIONS="${ENV_VARS['APP_POEM_REACTIONS']}" \
     HOST="${ENV_VARS['HOST']}" \
     IMG_CDN_PUB_KEY="${ENV_VARS['IMG_CDN_PUB_KEY']}" \
     IMG_CDN_PRI_KEY="${ENV_VARS['IMG_CDN_PRI_KEY']}" \
     IMG_CDN_URL_EPT="${ENV_VARS['IMG_CDN_URL_EPT']}" \
     GOOGLE_MAIL_SENDER="${ENV_VARS['GOOGLE_MAIL_SENDER']}" \
+    APP_MAIL_DIR="${ENV_VARS['APP_MAIL_DIR']}" \
+    APP_ADMIN_IDS="${ENV_VARS['APP_ADMIN_IDS']}" \
+    APP_SEARCH_BACKEND="${ENV_VARS['APP_SEARCH_BACKEND']}" \
+    APP_SEARCH_INDEX_DIR="${ENV_VARS['APP_SEARCH_INDEX_DIR']}" \
     WEB_CLIENT_DOMAIN="${ENV_VARS['WEB_CLIENT_DOMAIN']}" \
     PWD_SALT="${ENV_VARS['PWD_SALT']}" \
     APP_SECRET_KEY="${ENV_VARS['APP_SECRET_KEY']}" \
@@ -25,31 +25,83 @@ func AddEndpoints(ginEngine *gin.Engine) {
 
 		v1.GET("/comment", controllers.GetComment)
 		v1.POST("/comment", controllers.AddComment)
+		v1.PUT("/comment", controllers.UpdateComment)
 		v1.DELETE("/comment", controllers.RemoveComment)
+		v1.GET("/comment/revisions", controllers.GetCommentRevisions)
+		v1.PUT("/like-comment", controllers.ChangeCommentLike)
 		v1.GET("/comments-of-poem", controllers.GetPoemComments)
 		v1.GET("/comment-replies", controllers.GetRepliesToComment)
+		v1.GET("/comment-thread", controllers.GetCommentThread)
 		v1.GET("/comments-by-user", controllers.GetUserComments)
+		v1.GET("/annotations", controllers.GetPoemAnnotations)
+		v1.GET("/mentions", controllers.GetMentions)
+
+		v1.GET("/notifications", controllers.GetNotifications)
+		v1.GET("/notifications/unread-count", controllers.GetUnreadNotificationsCount)
+		v1.PUT("/notifications/read", controllers.MarkNotificationsRead)
+		v1.GET("/stream", controllers.GetEventStream)
+		v1.GET("/webhooks", controllers.GetWebhooks)
+		v1.POST("/webhook", controllers.AddWebhook)
+		v1.PUT("/webhook", controllers.UpdateWebhook)
+		v1.DELETE("/webhook", controllers.RemoveWebhook)
+		v1.GET("/webhook/deliveries", controllers.GetWebhookDeliveries)
 
 		v1.GET("/followers", controllers.GetFollowers)
 		v1.GET("/followings", controllers.GetFollowings)
 		v1.PUT("/follow", controllers.ChangeConnection)
+		v1.PUT("/block", controllers.ChangeBlock)
+		v1.GET("/suggestions/people", controllers.GetPeopleSuggestions)
 
 		v1.GET("/poem", controllers.GetPoem)
 		v1.POST("/poem", controllers.AddPoem)
 		v1.PUT("/poem", controllers.UpdatePoem)
 		v1.DELETE("/poem", controllers.RemovePoem)
+		v1.GET("/poem/similar", controllers.GetSimilarPoems)
+		v1.GET("/poem/export", controllers.ExportPoem)
+		v1.GET("/poem/revisions", controllers.GetPoemRevisions)
+		v1.GET("/poem/revisions/diff", controllers.GetPoemRevisionsDiff)
+		v1.PUT("/poem/revisions/restore", controllers.RestorePoemRevision)
 		v1.PUT("/like-poem", controllers.ChangePoemReaction)
+		v1.GET("/poem-reactions", controllers.GetPoemReactions)
+		v1.GET("/reactions", controllers.GetReactionTypes)
+		v1.PUT("/bookmark", controllers.ChangeBookmark)
+		v1.GET("/bookmarks", controllers.GetBookmarks)
 		v1.GET("/poems-user-created", controllers.GetPoemsUserCreated)
+		v1.GET("/poems-user-created/export", controllers.ExportUserPoems)
 		v1.GET("/poems-user-likes", controllers.GetPoemsUserLikes)
 		v1.GET("/poems-channel", controllers.GetPoemsForChannel)
 		v1.GET("/poems-explore", controllers.GetPoemsToExplore)
+		v1.GET("/poems-drafts", controllers.GetPoemDrafts)
+		v1.PUT("/poem-draft", controllers.UpdatePoemDraft)
+
+		v1.GET("/collection", controllers.GetCollection)
+		v1.POST("/collection", controllers.AddCollection)
+		v1.PUT("/collection", controllers.UpdateCollection)
+		v1.DELETE("/collection", controllers.RemoveCollection)
+		v1.GET("/collections-by-user", controllers.GetUserCollections)
+		v1.GET("/collection-poems", controllers.GetCollectionPoems)
+		v1.POST("/collection-poems", controllers.AddCollectionPoem)
+		v1.DELETE("/collection-poems", controllers.RemoveCollectionPoem)
+		v1.PUT("/collection-poems", controllers.ReorderCollectionPoems)
+		v1.GET("/collection/export", controllers.ExportCollection)
+
+		v1.GET("/tags/autocomplete", controllers.GetTagSuggestions)
+		v1.GET("/tags/trending", controllers.GetTrendingTags)
+		v1.GET("/tags/:name/poems", controllers.GetTagPoems)
 
+		v1.GET("/search", controllers.Search)
 		v1.GET("/search-poems", controllers.FindPoems)
 		v1.GET("/search-people", controllers.FindPeople)
+		v1.GET("/autocomplete", controllers.GetAutocompleteSuggestions)
+		v1.POST("/search/reindex", controllers.ReindexSearch)
 
 		v1.GET("/user", controllers.GetUser)
 		v1.PUT("/user", controllers.UpdateUser)
 		v1.DELETE("/user", controllers.RemoveUser)
+		v1.PUT("/user/handle", controllers.UpdateUserHandle)
+		v1.GET("/user/preferences", controllers.GetUserPreferences)
+		v1.PUT("/user/preferences", controllers.UpdateUserPreferences)
+		v1.GET("/users/by-handle/:handle", controllers.GetUserByHandle)
 	}
 	ginEngine.NoRoute(func(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": "Page not found."})
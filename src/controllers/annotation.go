@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// Moves the annotations of a poem from its old verses to its new verses,
+// orphaning the annotations whose anchored text no longer exists.
+func remapPoemAnnotations(db *sqlx.DB, tx *sql.Tx, poemId, oldText, newText string) error {
+	oldVerses, err := utils.GetPoemVerses(oldText)
+	if err != nil {
+		return err
+	}
+	newVerses, err := utils.GetPoemVerses(newText)
+	if err != nil {
+		return err
+	}
+	annotations := []db_models.Comment{}
+	err = db.Select(
+		&annotations,
+		"SELECT * FROM comments WHERE poem_id=$1 AND verse_index>=0;",
+		poemId,
+	)
+	if err != nil {
+		return err
+	}
+	for _, annotation := range annotations {
+		anchor := utils.RemapVerseAnchor(oldVerses, newVerses, utils.VerseAnchor{
+			VerseIndex: annotation.VerseIndex,
+			RangeStart: annotation.RangeStart,
+			RangeEnd:   annotation.RangeEnd,
+			Text:       annotation.AnchorText,
+			IsOrphaned: annotation.IsOrphaned,
+		})
+		_, err = tx.Exec(
+			`UPDATE comments SET
+				verse_index=$1, range_start=$2, range_end=$3, is_orphaned=$4
+				WHERE id=$5;`,
+			anchor.VerseIndex,
+			anchor.RangeStart,
+			anchor.RangeEnd,
+			anchor.IsOrphaned,
+			annotation.Id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Retrieves the annotations on a poem grouped by the verse they're anchored
+// to, leaving out those of users who have a block with the current user.
+func GetPoemAnnotations(c *gin.Context) {
+	poemId := c.Query("id")
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	poem := &db_models.Poem{}
+	err = db.Get(
+		poem,
+		"SELECT * FROM poems WHERE id=$1 AND status='published';",
+		poemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	verses, err := utils.GetPoemVerses(poem.Text)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	viewerId := ""
+	if authToken != nil {
+		viewerId = authToken.UserId
+	}
+	annotations := []db_models.Comment{}
+	err = db.Select(
+		&annotations,
+		"SELECT * FROM comments WHERE poem_id=$1 AND verse_index>=0 AND "+
+			getUnblockedUserCondition("user_id", "$2")+";",
+		poemId,
+		viewerId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(annotations, func(i, j int) bool {
+		if annotations[i].RangeStart != annotations[j].RangeStart {
+			return annotations[i].RangeStart < annotations[j].RangeStart
+		}
+		return annotations[i].CreatedOn.Before(annotations[j].CreatedOn)
+	})
+	versesAnnotations := make([][]response_models.Comment, len(verses))
+	orphanedAnnotations := []response_models.Comment{}
+	for i := range annotations {
+		annotationObj, err := getCommentObj(db, &annotations[i], authToken)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		verseIndex := annotations[i].VerseIndex
+		if annotations[i].IsOrphaned || verseIndex >= len(verses) {
+			orphanedAnnotations = append(orphanedAnnotations, *annotationObj)
+		} else {
+			versesAnnotations[verseIndex] = append(versesAnnotations[verseIndex], *annotationObj)
+		}
+	}
+	versesObjs := []gin.H{}
+	for i, verseAnnotations := range versesAnnotations {
+		if len(verseAnnotations) == 0 {
+			continue
+		}
+		versesObjs = append(versesObjs, gin.H{
+			"verseIndex":  i,
+			"verse":       verses[i],
+			"annotations": verseAnnotations,
+		})
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"verses":   versesObjs,
+				"orphaned": orphanedAnnotations,
+			},
+		},
+	)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -165,6 +166,25 @@ func SignUp(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	handle := jsonBody.Handle
+	if len(handle) > 0 {
+		err = utils.ValidateHandle(handle)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		isAvailable, err := isHandleAvailable(db, handle, userId, currentTime)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if !isAvailable {
+			c.JSON(200, gin.H{"success": false, "message": "Handle is already taken."})
+			return
+		}
+	} else {
+		handle = utils.GenerateHandle(jsonBody.Name, userId)
+	}
 	tx, err := db.Begin()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -172,15 +192,16 @@ func SignUp(c *gin.Context) {
 	}
 	_, err = tx.Exec(
 		`INSERT INTO users(
-			id, created_on, updated_on, email, name, password_hash
+			id, created_on, updated_on, email, name, password_hash, handle
 		)
-		VALUES($1, $2, $3, $4, $5, $6);`,
+		VALUES($1, $2, $3, $4, $5, $6, $7);`,
 		userId,
 		currentTime.Format(time.RFC3339),
 		currentTime.Format(time.RFC3339),
 		jsonBody.Email,
 		jsonBody.Name,
 		pwdHash,
+		handle,
 	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -191,6 +212,11 @@ func SignUp(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	search.SyncUser(&db_models.User{
+		Id:     userId,
+		Name:   jsonBody.Name,
+		Handle: handle,
+	})
 	authToken := &utils.AuthToken{
 		UserId:     userId,
 		Email:      jsonBody.Email,
@@ -209,6 +235,7 @@ func SignUp(c *gin.Context) {
 			"data": gin.H{
 				"userId":    userId,
 				"name":      jsonBody.Name,
+				"handle":    handle,
 				"authToken": token,
 			},
 		},
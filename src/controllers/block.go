@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/timelines"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// The condition of the users in the column %[1]s who haven't blocked and
+// weren't blocked by the user whose id is the query argument %[2]s.
+// Rows without a user, such as deleted users' tombstones, always match.
+const unblockedUserCondition = `NOT EXISTS (SELECT 1 FROM users_blocks
+	WHERE (blocker_id=%[2]s AND blocked_id=%[1]s) OR (blocker_id=%[1]s AND blocked_id=%[2]s))`
+
+// Creates the condition of the users in a column who have
+// no block with the user whose id is the given query argument.
+func getUnblockedUserCondition(column, userIdArg string) string {
+	return fmt.Sprintf(unblockedUserCondition, column, userIdArg)
+}
+
+// Checks if either of two users has blocked the other.
+func isBlocked(db *sqlx.DB, userId, otherUserId string) (bool, error) {
+	blocksCount := 0
+	err := db.Get(
+		&blocksCount,
+		`SELECT COUNT(*) FROM users_blocks
+		WHERE (blocker_id=$1 AND blocked_id=$2) OR (blocker_id=$2 AND blocked_id=$1);`,
+		userId,
+		otherUserId,
+	)
+	return blocksCount > 0, err
+}
+
+// Toggles a user's block on another user. Blocking a user
+// removes the connections between both users.
+func ChangeBlock(c *gin.Context) {
+	var jsonBody request_models.BlockForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	if jsonBody.BlockId == jsonBody.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "You cannot block yourself."})
+		return
+	}
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", jsonBody.BlockId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+		return
+	}
+	userBlock := &db_models.UserBlock{}
+	err = db.Get(
+		userBlock,
+		"SELECT * FROM users_blocks WHERE blocker_id=$1 AND blocked_id=$2;",
+		jsonBody.UserId,
+		jsonBody.BlockId,
+	)
+	isBlocking := err != nil
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if isBlocking {
+		_, err = tx.Exec(
+			`INSERT INTO users_blocks (id, blocker_id, blocked_id, created_on)
+			VALUES ($1, $2, $3, $4);`,
+			uuid.New().String(),
+			jsonBody.UserId,
+			jsonBody.BlockId,
+			time.Now().UTC(),
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		_, err = tx.Exec(
+			`DELETE FROM users_followings
+			WHERE (follower_id=$1 AND following_id=$2) OR (follower_id=$2 AND following_id=$1);`,
+			jsonBody.UserId,
+			jsonBody.BlockId,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = timelines.RemoveFollowing(tx, jsonBody.UserId, jsonBody.BlockId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = timelines.RemoveFollowing(tx, jsonBody.BlockId, jsonBody.UserId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	} else {
+		_, err = tx.Exec("DELETE FROM users_blocks WHERE id=$1;", userBlock.Id)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{"status": isBlocking},
+		},
+	)
+}
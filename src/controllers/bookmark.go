@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Toggles a user's bookmark on a poem.
+func ChangeBookmark(c *gin.Context) {
+	var jsonBody request_models.BookmarkForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	bookmark := &db_models.Bookmark{}
+	err = db.Get(
+		bookmark,
+		"SELECT * FROM bookmarks WHERE user_id=$1 AND poem_id=$2;",
+		jsonBody.UserId,
+		jsonBody.PoemId,
+	)
+	if err == nil {
+		// bookmark exists -> remove bookmark
+		tx, err := db.Begin()
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		_, err = tx.Exec(
+			"DELETE FROM bookmarks WHERE user_id=$1 AND poem_id=$2;",
+			jsonBody.UserId,
+			jsonBody.PoemId,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = tx.Commit()
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(
+			200,
+			gin.H{
+				"success": true,
+				"data":    gin.H{"status": false},
+			},
+		)
+	} else {
+		// bookmark doesn't exist -> create bookmark
+		poem := &db_models.Poem{}
+		err = db.Get(
+			poem,
+			"SELECT * FROM poems WHERE id=$1 AND status='published';",
+			jsonBody.PoemId,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": "Poem doesn't exist."})
+			return
+		}
+		newBookmark := &db_models.Bookmark{
+			Id:        uuid.New().String(),
+			UserId:    jsonBody.UserId,
+			PoemId:    jsonBody.PoemId,
+			CreatedOn: time.Now().UTC(),
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		_, err = tx.Exec(
+			`INSERT INTO bookmarks (id, user_id, poem_id, created_on)
+			VALUES ($1, $2, $3, $4);`,
+			newBookmark.Id,
+			newBookmark.UserId,
+			newBookmark.PoemId,
+			newBookmark.CreatedOn,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = tx.Commit()
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(
+			200,
+			gin.H{
+				"success": true,
+				"data":    gin.H{"status": true},
+			},
+		)
+	}
+}
+
+// Retrieves the poems bookmarked by the current user.
+func GetBookmarks(c *gin.Context) {
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	bookmarks := []db_models.Bookmark{}
+	err = db.Select(
+		&bookmarks,
+		`SELECT bookmarks.* FROM bookmarks
+		INNER JOIN poems ON poems.id=bookmarks.poem_id
+		WHERE bookmarks.user_id=$1 AND poems.status='published';`,
+		authToken.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(bookmarks, func(i, j int) bool {
+		return bookmarks[i].CreatedOn.After(bookmarks[j].CreatedOn)
+	})
+	pageBookmarks, err := utils.ExtractPage(bookmarks, *pageSpec)
+	pagePoemsObjs := make([]response_models.Poem, len(pageBookmarks))
+	for i := range pageBookmarks {
+		poem := &db_models.Poem{}
+		err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", pageBookmarks[i].PoemId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+			return
+		}
+		poemObj, err := getPoemObj(db, poem, authToken)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pagePoemsObjs[i] = *poemObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pagePoemsObjs,
+		},
+	)
+}
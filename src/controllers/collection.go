@@ -0,0 +1,782 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Checks if a collection's title, description and visibility are valid.
+func validateCollection(title, description, visibility string) error {
+	if len(strings.Trim(title, " ")) < 1 {
+		return errors.New("Title is too short.")
+	}
+	if len(title) > 128 {
+		return errors.New("Title is too long.")
+	}
+	if len(description) > 384 {
+		return errors.New("Description is too long.")
+	}
+	if visibility != db_models.CollectionVisibilityPublic &&
+		visibility != db_models.CollectionVisibilityPrivate {
+		return errors.New("Invalid visibility.")
+	}
+	return nil
+}
+
+// Removes the entries of poems in collections whose ids are selected by
+// the given query and closes the gaps they leave in the positions of the
+// remaining poems of their collections.
+func removeCollectionsPoems(tx *sql.Tx, idsQuery string, args ...any) error {
+	rows, err := tx.Query(
+		"DELETE FROM collections_poems WHERE id IN ("+idsQuery+") RETURNING collection_id;",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	collectionsIds := []string{}
+	for rows.Next() {
+		collectionId := ""
+		err = rows.Scan(&collectionId)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		collectionsIds = append(collectionsIds, collectionId)
+	}
+	rows.Close()
+	if len(collectionsIds) == 0 {
+		return nil
+	}
+	_, err = tx.Exec(
+		`UPDATE collections_poems SET position=ranked.position
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY collection_id ORDER BY position) - 1 AS position
+			FROM collections_poems WHERE collection_id=ANY($1)
+		) AS ranked
+		WHERE collections_poems.id=ranked.id AND collections_poems.position<>ranked.position;`,
+		pq.Array(collectionsIds),
+	)
+	return err
+}
+
+// Checks if a collection can be viewed by the owner of an auth token.
+func canViewCollection(collection *db_models.Collection, authToken *utils.AuthToken) bool {
+	return collection.Visibility == db_models.CollectionVisibilityPublic ||
+		(authToken != nil && authToken.UserId == collection.UserId)
+}
+
+// Creates the response object of a collection.
+func getCollectionObj(
+	db *sqlx.DB,
+	collection *db_models.Collection,
+	user *db_models.User,
+) (*response_models.Collection, error) {
+	poemsCount := 0
+	err := db.Get(
+		&poemsCount,
+		`SELECT COUNT(*) AS poems_count FROM collections_poems
+		INNER JOIN poems ON poems.id=collections_poems.poem_id
+		WHERE collections_poems.collection_id=$1 AND poems.status='published';`,
+		collection.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &response_models.Collection{
+		Id: collection.Id,
+		User: response_models.UserMin{
+			Id:             user.Id,
+			Name:           user.Name,
+			Handle:         user.Handle,
+			ProfilePhotoId: user.ProfilePhotoId,
+		},
+		Title:       collection.Title,
+		Description: collection.Description,
+		Visibility:  collection.Visibility,
+		PoemsCount:  poemsCount,
+		CreatedOn:   collection.CreatedOn.UTC().Format(time.RFC3339),
+		UpdatedOn:   collection.UpdatedOn.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// Retrieves information about a given collection.
+func GetCollection(c *gin.Context) {
+	collectionId := c.Query("id")
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	collection := &db_models.Collection{}
+	err = db.Get(collection, "SELECT * FROM collections WHERE id=$1;", collectionId)
+	if err != nil || !canViewCollection(collection, authToken) {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection."})
+		return
+	}
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", collection.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection creator."})
+		return
+	}
+	collectionObj, err := getCollectionObj(db, collection, user)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    collectionObj,
+		},
+	)
+}
+
+// Creates a new collection.
+func AddCollection(c *gin.Context) {
+	var jsonBody request_models.CollectionAddForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	err = validateCollection(jsonBody.Title, jsonBody.Description, jsonBody.Visibility)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	currentTime := time.Now().UTC()
+	collection := &db_models.Collection{
+		Id:          uuid.New().String(),
+		UserId:      authToken.UserId,
+		Title:       jsonBody.Title,
+		Description: jsonBody.Description,
+		Visibility:  jsonBody.Visibility,
+		CreatedOn:   currentTime,
+		UpdatedOn:   currentTime,
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`INSERT INTO collections (
+			id, user_id, title, description, visibility, created_on, updated_on
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+		collection.Id,
+		collection.UserId,
+		collection.Title,
+		collection.Description,
+		collection.Visibility,
+		collection.CreatedOn,
+		collection.UpdatedOn,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"id":         collection.Id,
+				"createdOn":  currentTime.Format(time.RFC3339),
+				"poemsCount": 0,
+			},
+		},
+	)
+}
+
+// Edits an existing collection.
+func UpdateCollection(c *gin.Context) {
+	var jsonBody request_models.CollectionUpdateForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	err = validateCollection(jsonBody.Title, jsonBody.Description, jsonBody.Visibility)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	collection := &db_models.Collection{}
+	err = db.Get(collection, "SELECT * FROM collections WHERE id=$1;", jsonBody.CollectionId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection."})
+		return
+	}
+	if collection.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "You are not allowed to edit this collection."})
+		return
+	}
+	currentTime := time.Now().UTC()
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`UPDATE collections SET
+			title=$1, description=$2, visibility=$3, updated_on=$4
+			WHERE id=$5;`,
+		jsonBody.Title,
+		jsonBody.Description,
+		jsonBody.Visibility,
+		currentTime,
+		jsonBody.CollectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{},
+		},
+	)
+}
+
+// Deletes a collection.
+func RemoveCollection(c *gin.Context) {
+	var jsonBody request_models.CollectionDeleteForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	collection := &db_models.Collection{}
+	err = db.Get(collection, "SELECT * FROM collections WHERE id=$1;", jsonBody.CollectionId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Collection doesn't exist."})
+		return
+	} else if collection.UserId != authToken.UserId {
+		c.JSON(200, gin.H{
+			"success": false,
+			"message": "Only the owner of the collection can delete the collection.",
+		})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"DELETE FROM collections_poems WHERE collection_id=$1;",
+		jsonBody.CollectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"DELETE FROM collections WHERE id=$1;",
+		jsonBody.CollectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{},
+		},
+	)
+}
+
+// Retrieves the collections created by a given user.
+func GetUserCollections(c *gin.Context) {
+	userId := c.Query("id")
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", userId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+		return
+	}
+	collections := []db_models.Collection{}
+	if authToken != nil && authToken.UserId == userId {
+		err = db.Select(
+			&collections,
+			"SELECT * FROM collections WHERE user_id=$1;",
+			userId,
+		)
+	} else {
+		err = db.Select(
+			&collections,
+			"SELECT * FROM collections WHERE user_id=$1 AND visibility='public';",
+			userId,
+		)
+	}
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(collections, func(i, j int) bool {
+		return collections[i].UpdatedOn.After(collections[j].UpdatedOn)
+	})
+	pageCollections, err := utils.ExtractPage(collections, *pageSpec)
+	pageCollectionsObjs := make([]response_models.Collection, len(pageCollections))
+	for i := range pageCollections {
+		collectionObj, err := getCollectionObj(db, &pageCollections[i], user)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pageCollectionsObjs[i] = *collectionObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageCollectionsObjs,
+		},
+	)
+}
+
+// Retrieves the poems in a collection in their curated order.
+func GetCollectionPoems(c *gin.Context) {
+	collectionId := c.Query("id")
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	collection := &db_models.Collection{}
+	err = db.Get(collection, "SELECT * FROM collections WHERE id=$1;", collectionId)
+	if err != nil || !canViewCollection(collection, authToken) {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection."})
+		return
+	}
+	poems := []db_models.Poem{}
+	err = db.Select(
+		&poems,
+		`SELECT poems.* FROM poems
+		INNER JOIN collections_poems ON collections_poems.poem_id=poems.id
+		WHERE collections_poems.collection_id=$1 AND poems.status='published'
+		ORDER BY collections_poems.position;`,
+		collectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
+	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
+	for i := range pagePoems {
+		poemObj, err := getPoemObj(db, &pagePoems[i], authToken)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pagePoemsObjs[i] = *poemObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pagePoemsObjs,
+		},
+	)
+}
+
+// Adds a poem to a collection at an optional position.
+func AddCollectionPoem(c *gin.Context) {
+	var jsonBody request_models.CollectionPoemForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	collection := &db_models.Collection{}
+	err = db.Get(collection, "SELECT * FROM collections WHERE id=$1;", jsonBody.CollectionId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection."})
+		return
+	}
+	if collection.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "You are not allowed to edit this collection."})
+		return
+	}
+	poem := &db_models.Poem{}
+	err = db.Get(
+		poem,
+		"SELECT * FROM poems WHERE id=$1 AND status='published';",
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	collectionPoem := &db_models.CollectionPoem{}
+	err = db.Get(
+		collectionPoem,
+		"SELECT * FROM collections_poems WHERE collection_id=$1 AND poem_id=$2;",
+		jsonBody.CollectionId,
+		jsonBody.PoemId,
+	)
+	if err == nil {
+		c.JSON(200, gin.H{"success": false, "message": "Poem is already in the collection."})
+		return
+	}
+	nextPosition := 0
+	err = db.Get(
+		&nextPosition,
+		"SELECT COALESCE(MAX(position)+1, 0) AS next_position FROM collections_poems WHERE collection_id=$1;",
+		jsonBody.CollectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	position := nextPosition
+	if jsonBody.Position != nil && *jsonBody.Position >= 0 && *jsonBody.Position < nextPosition {
+		position = *jsonBody.Position
+	}
+	currentTime := time.Now().UTC()
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`UPDATE collections_poems SET position=position+1
+		WHERE collection_id=$1 AND position>=$2;`,
+		jsonBody.CollectionId,
+		position,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`INSERT INTO collections_poems (id, collection_id, poem_id, position, created_on)
+		VALUES ($1, $2, $3, $4, $5);`,
+		uuid.New().String(),
+		jsonBody.CollectionId,
+		jsonBody.PoemId,
+		position,
+		currentTime,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"UPDATE collections SET updated_on=$1 WHERE id=$2;",
+		currentTime,
+		jsonBody.CollectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{"position": position},
+		},
+	)
+}
+
+// Removes a poem from a collection.
+func RemoveCollectionPoem(c *gin.Context) {
+	var jsonBody request_models.CollectionPoemForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	collection := &db_models.Collection{}
+	err = db.Get(collection, "SELECT * FROM collections WHERE id=$1;", jsonBody.CollectionId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection."})
+		return
+	}
+	if collection.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "You are not allowed to edit this collection."})
+		return
+	}
+	collectionPoem := &db_models.CollectionPoem{}
+	err = db.Get(
+		collectionPoem,
+		"SELECT * FROM collections_poems WHERE collection_id=$1 AND poem_id=$2;",
+		jsonBody.CollectionId,
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Poem isn't in the collection."})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"DELETE FROM collections_poems WHERE id=$1;",
+		collectionPoem.Id,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`UPDATE collections_poems SET position=position-1
+		WHERE collection_id=$1 AND position>$2;`,
+		jsonBody.CollectionId,
+		collectionPoem.Position,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"UPDATE collections SET updated_on=$1 WHERE id=$2;",
+		time.Now().UTC(),
+		jsonBody.CollectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{},
+		},
+	)
+}
+
+// Changes the order of the poems in a collection.
+func ReorderCollectionPoems(c *gin.Context) {
+	var jsonBody request_models.CollectionOrderForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	collection := &db_models.Collection{}
+	err = db.Get(collection, "SELECT * FROM collections WHERE id=$1;", jsonBody.CollectionId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection."})
+		return
+	}
+	if collection.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "You are not allowed to edit this collection."})
+		return
+	}
+	poemIds := []string{}
+	err = db.Select(
+		&poemIds,
+		"SELECT poem_id FROM collections_poems WHERE collection_id=$1;",
+		jsonBody.CollectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	isMember := make(map[string]bool, len(poemIds))
+	for _, poemId := range poemIds {
+		isMember[poemId] = true
+	}
+	if len(jsonBody.PoemIds) != len(poemIds) {
+		c.JSON(200, gin.H{"success": false, "message": "All poems in the collection must be ordered."})
+		return
+	}
+	for _, poemId := range jsonBody.PoemIds {
+		if !isMember[poemId] {
+			c.JSON(200, gin.H{"success": false, "message": "All poems in the collection must be ordered."})
+			return
+		}
+		isMember[poemId] = false
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	for i, poemId := range jsonBody.PoemIds {
+		_, err = tx.Exec(
+			"UPDATE collections_poems SET position=$1 WHERE collection_id=$2 AND poem_id=$3;",
+			i,
+			jsonBody.CollectionId,
+			poemId,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
+	_, err = tx.Exec(
+		"UPDATE collections SET updated_on=$1 WHERE id=$2;",
+		time.Now().UTC(),
+		jsonBody.CollectionId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{},
+		},
+	)
+}
@@ -1,17 +1,35 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/notifications"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
+// The maximum depth of a reply in a comment thread.
+const MAX_COMMENT_DEPTH = 8
+
+// The maximum number of levels of replies retrieved in a comment thread.
+const MAX_THREAD_LEVELS = 4
+
+// The maximum number of replies retrieved under a nested comment in a thread.
+const MAX_THREAD_REPLIES = 5
+
+// The period after a comment is made in which its author can edit it.
+const COMMENT_EDIT_WINDOW = 15 * time.Minute
+
 // Retrieves information about a given comment.
 func GetComment(c *gin.Context) {
 	commentId := c.Query("id")
@@ -20,37 +38,23 @@ func GetComment(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
 	comment := &db_models.Comment{}
 	err = db.Get(comment, "SELECT * FROM comments WHERE id=$1;", commentId)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment."})
 		return
 	}
-	user := &db_models.User{}
-	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", comment.UserId)
+	commentObj, err := getCommentObj(db, comment, authToken)
 	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment creator."})
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	replies := []db_models.Comment{}
-	db.Select(&replies, "SELECT * FROM comments WHERE comment_id=$1;", commentId)
 	c.JSON(
 		200,
 		gin.H{
 			"success": true,
-			"data": gin.H{
-				"id": comment.Id,
-				"user": gin.H{
-					"id":             user.Id,
-					"name":           user.Name,
-					"profilePhotoId": user.ProfilePhotoId,
-				},
-				"createdOn":    comment.CreatedOn.UTC().Format(time.RFC3339),
-				"text":         comment.Text,
-				"poemId":       comment.PoemId,
-				"repliesCount": len(replies),
-				"replyTo":      comment.CommentId,
-			},
+			"data":    commentObj,
 		},
 	)
 }
@@ -81,18 +85,24 @@ func AddComment(c *gin.Context) {
 	commentId := uuid.New().String()
 	comment := &db_models.Comment{
 		Id:        commentId,
-		UserId:    authToken.UserId,
+		UserId:    sql.NullString{String: authToken.UserId, Valid: true},
 		PoemId:    jsonBody.PoemId,
-		CommentId: commentId,
+		CommentId: jsonBody.ReplyTo,
 		Text:      jsonBody.Text,
 		CreatedOn: currentTime,
+		UpdatedOn: currentTime,
 	}
 	if len(jsonBody.Text) > 384 {
 		c.JSON(200, gin.H{"success": false, "message": "Name is too long."})
 		return
 	}
+	isAnnotation := jsonBody.VerseIndex != nil
+	if isAnnotation && len(jsonBody.ReplyTo) > 0 {
+		c.JSON(200, gin.H{"success": false, "message": "Replies cannot be anchored to verses."})
+		return
+	}
+	parentComment := &db_models.Comment{}
 	if len(jsonBody.ReplyTo) > 0 {
-		parentComment := &db_models.Comment{}
 		err = db.Get(
 			parentComment,
 			"SELECT * FROM comments WHERE id=$1 AND poem_id=$2;",
@@ -103,36 +113,134 @@ func AddComment(c *gin.Context) {
 			c.JSON(200, gin.H{"success": false, "message": "Failed to find comment being replied to."})
 			return
 		}
+		if parentComment.IsDeleted {
+			c.JSON(200, gin.H{"success": false, "message": "Cannot reply to a deleted comment."})
+			return
+		}
+		if parentComment.Depth+1 > MAX_COMMENT_DEPTH {
+			c.JSON(200, gin.H{"success": false, "message": "Thread is too deep."})
+			return
+		}
+		comment.Depth = parentComment.Depth + 1
 	}
 	poem := &db_models.Poem{}
 	err = db.Get(
 		poem,
-		"SELECT * FROM poems WHERE id=$1;",
+		"SELECT * FROM poems WHERE id=$1 AND status='published';",
 		jsonBody.PoemId,
 	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem with id."})
 		return
 	}
+	hasBlock, err := isBlocked(db, authToken.UserId, poem.UserId)
+	if err == nil && !hasBlock && len(jsonBody.ReplyTo) > 0 {
+		hasBlock, err = isBlocked(db, authToken.UserId, parentComment.UserId.String)
+	}
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if hasBlock {
+		c.JSON(200, gin.H{"success": false, "message": "You cannot comment on this poem."})
+		return
+	}
+	comment.VerseIndex, comment.RangeStart, comment.RangeEnd = -1, -1, -1
+	if isAnnotation {
+		verses, err := utils.GetPoemVerses(poem.Text)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		anchor, err := utils.NewVerseAnchor(
+			verses,
+			*jsonBody.VerseIndex,
+			jsonBody.RangeStart,
+			jsonBody.RangeEnd,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		comment.VerseIndex = anchor.VerseIndex
+		comment.RangeStart = anchor.RangeStart
+		comment.RangeEnd = anchor.RangeEnd
+		comment.AnchorText = anchor.Text
+	}
 	tx, err := db.Begin()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 	_, err = tx.Exec(
-		`INSERT INTO comments (id, user_id, poem_id, comment_id, text, created_on)
-		VALUES ($1, $2, $3, $4, $5, $6);`,
+		`INSERT INTO comments (
+			id, user_id, poem_id, comment_id, text, created_on, updated_on,
+			verse_index, range_start, range_end, anchor_text, depth
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);`,
 		comment.Id,
 		comment.UserId,
 		comment.PoemId,
 		comment.CommentId,
 		comment.Text,
 		comment.CreatedOn,
+		comment.UpdatedOn,
+		comment.VerseIndex,
+		comment.RangeStart,
+		comment.RangeEnd,
+		comment.AnchorText,
+		comment.Depth,
 	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	err = setCommentMentions(tx, comment, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if len(jsonBody.ReplyTo) > 0 {
+		err = notifications.Add(tx, db_models.Notification{
+			UserId:    parentComment.UserId.String,
+			ActorId:   comment.UserId.String,
+			Type:      db_models.NotificationTypeReply,
+			PoemId:    comment.PoemId,
+			CommentId: comment.Id,
+			GroupKey:  notifications.GetGroupKey(db_models.NotificationTypeReply, parentComment.Id),
+			CreatedOn: currentTime,
+		})
+	} else {
+		err = notifications.Add(tx, db_models.Notification{
+			UserId:    poem.UserId,
+			ActorId:   comment.UserId.String,
+			Type:      db_models.NotificationTypeComment,
+			PoemId:    comment.PoemId,
+			CommentId: comment.Id,
+			GroupKey:  notifications.GetGroupKey(db_models.NotificationTypeComment, comment.PoemId),
+			CreatedOn: currentTime,
+		})
+	}
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = notifications.AddMentions(tx, comment.UserId.String, comment.PoemId, comment.Id, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = events.Publish(tx, events.Event{
+		Type:      events.EventCommentCreated,
+		UserId:    poem.UserId,
+		AuthorId:  comment.UserId.String,
+		PoemId:    comment.PoemId,
+		CommentId: comment.Id,
+	})
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	err = tx.Commit()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -148,12 +256,225 @@ func AddComment(c *gin.Context) {
 				"poemId":       jsonBody.PoemId,
 				"replyTo":      jsonBody.ReplyTo,
 				"repliesCount": 0,
+				"anchor":       getCommentAnchor(comment),
+				"depth":        comment.Depth,
+			},
+		},
+	)
+}
+
+// Updates the text of a comment within its edit window and keeps
+// the previous text in the comment's edit history.
+func UpdateComment(c *gin.Context) {
+	var jsonBody request_models.CommentUpdateForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	if len(jsonBody.Text) > 384 {
+		c.JSON(200, gin.H{"success": false, "message": "Text is too long."})
+		return
+	}
+	currentTime := time.Now().UTC()
+	comment := &db_models.Comment{}
+	err = db.Get(
+		comment,
+		"SELECT * FROM comments WHERE id=$1 AND is_deleted=FALSE;",
+		jsonBody.CommentId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment."})
+		return
+	}
+	if comment.UserId.String != authToken.UserId {
+		c.JSON(200, gin.H{
+			"success": false,
+			"message": "Only the author of the comment can edit the comment.",
+		})
+		return
+	}
+	if currentTime.Sub(comment.CreatedOn) > COMMENT_EDIT_WINDOW {
+		c.JSON(200, gin.H{"success": false, "message": "Comment can no longer be edited."})
+		return
+	}
+	if comment.Text == jsonBody.Text {
+		c.JSON(200, gin.H{"success": false, "message": "Comment has not changed."})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// keep the comment's current text as a revision
+	_, err = tx.Exec(
+		`INSERT INTO comment_revisions (id, comment_id, text, created_on)
+		VALUES ($1, $2, $3, $4);`,
+		uuid.New().String(),
+		comment.Id,
+		comment.Text,
+		comment.UpdatedOn,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"UPDATE comments SET updated_on=$1, text=$2 WHERE id=$3;",
+		currentTime,
+		jsonBody.Text,
+		jsonBody.CommentId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	comment.Text = jsonBody.Text
+	err = setCommentMentions(tx, comment, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = notifications.AddMentions(tx, comment.UserId.String, comment.PoemId, comment.Id, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"updatedOn": currentTime.Format(time.RFC3339),
+				"isEdited":  true,
+			},
+		},
+	)
+}
+
+// Toggles a user's like on a comment.
+func ChangeCommentLike(c *gin.Context) {
+	var jsonBody request_models.CommentLikeForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	commentLike := &db_models.CommentLike{}
+	err = db.Get(
+		commentLike,
+		"SELECT * FROM comments_likes WHERE user_id=$1 AND comment_id=$2;",
+		jsonBody.UserId,
+		jsonBody.CommentId,
+	)
+	isLiking := err != nil
+	if isLiking {
+		comment := &db_models.Comment{}
+		err = db.Get(
+			comment,
+			"SELECT * FROM comments WHERE id=$1 AND is_deleted=FALSE;",
+			jsonBody.CommentId,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": "Failed to find comment."})
+			return
+		}
+		hasBlock, err := isBlocked(db, jsonBody.UserId, comment.UserId.String)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if hasBlock {
+			c.JSON(200, gin.H{"success": false, "message": "You cannot like this comment."})
+			return
+		}
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if isLiking {
+		// like doesn't exist -> create like
+		_, err = tx.Exec(
+			`INSERT INTO comments_likes (id, user_id, comment_id, created_on)
+			VALUES ($1, $2, $3, $4);`,
+			uuid.New().String(),
+			jsonBody.UserId,
+			jsonBody.CommentId,
+			time.Now().UTC(),
+		)
+	} else {
+		// like exists -> remove like
+		_, err = tx.Exec("DELETE FROM comments_likes WHERE id=$1;", commentLike.Id)
+	}
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	likesCount := 0
+	err = db.Get(
+		&likesCount,
+		"SELECT COUNT(*) AS likes_count FROM comments_likes WHERE comment_id=$1;",
+		jsonBody.CommentId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"status":     isLiking,
+				"likesCount": likesCount,
 			},
 		},
 	)
 }
 
 // Deletes a comment made by a user.
+// Comments with replies are kept as tombstones so their threads stay intact.
 func RemoveComment(c *gin.Context) {
 	var jsonBody request_models.CommentDeleteForm
 	err := c.ShouldBindJSON(&jsonBody)
@@ -184,7 +505,10 @@ func RemoveComment(c *gin.Context) {
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment creator."})
 		return
-	} else if comment.UserId != authToken.UserId {
+	} else if comment.IsDeleted {
+		c.JSON(200, gin.H{"success": false, "message": "Comment has already been deleted."})
+		return
+	} else if comment.UserId.String != authToken.UserId {
 		c.JSON(200, gin.H{
 			"success": false,
 			"message": "Only the author of the comment can delete the comment.",
@@ -196,10 +520,23 @@ func RemoveComment(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	_, err = tx.Exec(
-		"DELETE FROM comments WHERE comment_id=$1 OR id=$1;",
+	repliesCount := 0
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM comments WHERE comment_id=$1;",
 		jsonBody.CommentId,
-	)
+	).Scan(&repliesCount)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if repliesCount > 0 {
+		err = tombstoneComments(tx, "SELECT $1::VARCHAR(36)", jsonBody.CommentId)
+	} else {
+		err = deleteComments(tx, "SELECT $1::VARCHAR(36)", jsonBody.CommentId)
+		if err == nil {
+			err = pruneDeletedComments(tx, comment.CommentId)
+		}
+	}
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
@@ -218,7 +555,8 @@ func RemoveComment(c *gin.Context) {
 	)
 }
 
-// Retrieves all comments made directly under a poem.
+// Retrieves all comments made directly under a poem, leaving out
+// those of users who have a block with the current user.
 func GetPoemComments(c *gin.Context) {
 	poemId := c.Query("id")
 	pageSpec, err := utils.GetPageSpec(c, true)
@@ -231,12 +569,19 @@ func GetPoemComments(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	viewerId := ""
+	if authToken != nil {
+		viewerId = authToken.UserId
+	}
 	comments := []db_models.Comment{}
 	err = db.Select(
 		&comments,
-		"SELECT * FROM comments WHERE poem_id=$1 AND comment_id=$2",
+		"SELECT * FROM comments WHERE poem_id=$1 AND comment_id=$2 AND "+
+			getUnblockedUserCondition("user_id", "$3"),
 		poemId,
 		"",
+		viewerId,
 	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -247,37 +592,13 @@ func GetPoemComments(c *gin.Context) {
 	})
 	pageComments, err := utils.ExtractPage(comments, *pageSpec)
 	pageCommentObjs := make([]response_models.Comment, len(pageComments))
-	for i := 0; i < len(pageComments); i++ {
-		user := &db_models.User{}
-		err = db.Get(user, "SELECT * FROM users WHERE id=$1;", pageComments[i].UserId)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": "Failed to find comment creator."})
-			return
-		}
-		replies := []db_models.Comment{}
-		err = db.Select(
-			&replies,
-			"SELECT * FROM comments WHERE poem_id=$1 AND comment_id=$2",
-			poemId,
-			pageComments[i].Id,
-		)
+	for i := range pageComments {
+		commentObj, err := getCommentObj(db, &pageComments[i], authToken)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		pageCommentObjs[i] = response_models.Comment{
-			Id: pageComments[i].Id,
-			User: response_models.UserMin{
-				Id:             user.Id,
-				Name:           user.Name,
-				ProfilePhotoId: user.ProfilePhotoId,
-			},
-			CreatedOn:    pageComments[i].CreatedOn.Format(time.RFC3339),
-			Text:         pageComments[i].Text,
-			PoemId:       pageComments[i].PoemId,
-			RepliesCount: len(replies),
-			ReplyTo:      pageComments[i].CommentId,
-		}
+		pageCommentObjs[i] = *commentObj
 	}
 	c.JSON(
 		200,
@@ -288,7 +609,8 @@ func GetPoemComments(c *gin.Context) {
 	)
 }
 
-// Retrieves all comments made directly under another comment.
+// Retrieves all comments made directly under another comment, leaving
+// out those of users who have a block with the current user.
 func GetRepliesToComment(c *gin.Context) {
 	commentId := c.Query("id")
 	pageSpec, err := utils.GetPageSpec(c, true)
@@ -301,21 +623,27 @@ func GetRepliesToComment(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
 	comment := db_models.Comment{}
-	err = db.Select(
+	err = db.Get(
 		&comment,
 		"SELECT * FROM comments WHERE id=$1",
 		commentId,
 	)
 	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment."})
 		return
 	}
+	viewerId := ""
+	if authToken != nil {
+		viewerId = authToken.UserId
+	}
 	comments := []db_models.Comment{}
 	err = db.Select(
 		&comments,
-		"SELECT * FROM comments WHERE comment_id=$1",
+		"SELECT * FROM comments WHERE comment_id=$1 AND "+getUnblockedUserCondition("user_id", "$2"),
 		commentId,
+		viewerId,
 	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -326,37 +654,13 @@ func GetRepliesToComment(c *gin.Context) {
 	})
 	pageReplies, err := utils.ExtractPage(comments, *pageSpec)
 	pageRepliesObjs := make([]response_models.Comment, len(pageReplies))
-	for i := 0; i < len(pageReplies); i++ {
-		user := &db_models.User{}
-		err = db.Get(user, "SELECT * FROM users WHERE id=$1;", pageReplies[i].UserId)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": "Failed to find comment creator."})
-			return
-		}
-		replies := []db_models.Comment{}
-		err = db.Select(
-			&replies,
-			"SELECT * FROM comments WHERE poem_id=$1 AND comment_id=$2",
-			comment.PoemId,
-			pageReplies[i].Id,
-		)
+	for i := range pageReplies {
+		commentObj, err := getCommentObj(db, &pageReplies[i], authToken)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		pageRepliesObjs[i] = response_models.Comment{
-			Id: pageReplies[i].Id,
-			User: response_models.UserMin{
-				Id:             user.Id,
-				Name:           user.Name,
-				ProfilePhotoId: user.ProfilePhotoId,
-			},
-			CreatedOn:    pageReplies[i].CreatedOn.Format(time.RFC3339),
-			Text:         pageReplies[i].Text,
-			PoemId:       pageReplies[i].PoemId,
-			RepliesCount: len(replies),
-			ReplyTo:      pageReplies[i].CommentId,
-		}
+		pageRepliesObjs[i] = *commentObj
 	}
 	c.JSON(
 		200,
@@ -380,20 +684,21 @@ func GetUserComments(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
 	user := db_models.User{}
-	err = db.Select(
+	err = db.Get(
 		&user,
 		"SELECT * FROM users WHERE id=$1",
 		userId,
 	)
 	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
 		return
 	}
 	comments := []db_models.Comment{}
 	err = db.Select(
 		&comments,
-		"SELECT * FROM comments WHERE user_id=$1",
+		"SELECT * FROM comments WHERE user_id=$1 AND is_deleted=FALSE",
 		userId,
 	)
 	if err != nil {
@@ -405,37 +710,13 @@ func GetUserComments(c *gin.Context) {
 	})
 	pageReplies, err := utils.ExtractPage(comments, *pageSpec)
 	pageRepliesObjs := make([]response_models.Comment, len(pageReplies))
-	for i := 0; i < len(pageReplies); i++ {
-		poem := &db_models.Poem{}
-		err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", pageReplies[i].PoemId)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": "Failed to find comment creator."})
-			return
-		}
-		replies := []db_models.Comment{}
-		err = db.Select(
-			&replies,
-			"SELECT * FROM comments WHERE poem_id=$1 AND comment_id=$2",
-			poem.Id,
-			pageReplies[i].Id,
-		)
+	for i := range pageReplies {
+		commentObj, err := getCommentObj(db, &pageReplies[i], authToken)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		pageRepliesObjs[i] = response_models.Comment{
-			Id: pageReplies[i].Id,
-			User: response_models.UserMin{
-				Id:             user.Id,
-				Name:           user.Name,
-				ProfilePhotoId: user.ProfilePhotoId,
-			},
-			CreatedOn:    pageReplies[i].CreatedOn.Format(time.RFC3339),
-			Text:         pageReplies[i].Text,
-			PoemId:       pageReplies[i].PoemId,
-			RepliesCount: len(replies),
-			ReplyTo:      pageReplies[i].CommentId,
-		}
+		pageRepliesObjs[i] = *commentObj
 	}
 	c.JSON(
 		200,
@@ -445,3 +726,312 @@ func GetUserComments(c *gin.Context) {
 		},
 	)
 }
+
+// Retrieves a comment and its replies as a tree. The direct replies to the
+// comment are paginated while nested replies are limited in number and depth.
+// Replies by users who have a block with the current user are left out
+// along with the replies under them.
+func GetCommentThread(c *gin.Context) {
+	commentId := c.Query("id")
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	levels, err := strconv.Atoi(c.DefaultQuery("levels", strconv.Itoa(MAX_THREAD_LEVELS)))
+	if err != nil || levels < 1 || levels > MAX_THREAD_LEVELS {
+		c.JSON(200, gin.H{"success": false, "message": "Invalid number of levels."})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	comment := &db_models.Comment{}
+	err = db.Get(comment, "SELECT * FROM comments WHERE id=$1;", commentId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment."})
+		return
+	}
+	viewerId := ""
+	if authToken != nil {
+		viewerId = authToken.UserId
+	}
+	comments := []db_models.Comment{}
+	err = db.Select(
+		&comments,
+		`WITH RECURSIVE thread AS (
+			SELECT * FROM comments WHERE comment_id=$1 AND `+getUnblockedUserCondition("comments.user_id", "$3")+`
+			UNION ALL
+			SELECT comments.* FROM comments
+			INNER JOIN thread ON comments.comment_id=thread.id
+			WHERE comments.depth<=$2 AND `+getUnblockedUserCondition("comments.user_id", "$3")+`
+		)
+		SELECT * FROM thread;`,
+		comment.Id,
+		comment.Depth+levels,
+		viewerId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedOn.After(comments[j].CreatedOn)
+	})
+	replies := map[string][]db_models.Comment{}
+	for _, reply := range comments {
+		replies[reply.CommentId] = append(replies[reply.CommentId], reply)
+	}
+	pageReplies, err := utils.ExtractPage(replies[comment.Id], *pageSpec)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	thread, err := getCommentThreadObj(db, comment, pageReplies, replies, authToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    thread,
+		},
+	)
+}
+
+// Creates the response object of a comment thread from the given page of
+// replies to its root comment and the replies retrieved under each comment.
+func getCommentThreadObj(
+	db *sqlx.DB,
+	comment *db_models.Comment,
+	pageReplies []db_models.Comment,
+	replies map[string][]db_models.Comment,
+	authToken *utils.AuthToken,
+) (*response_models.CommentThread, error) {
+	commentObj, err := getCommentObj(db, comment, authToken)
+	if err != nil {
+		return nil, err
+	}
+	thread := &response_models.CommentThread{
+		Comment: *commentObj,
+		Replies: make([]response_models.CommentThread, len(pageReplies)),
+	}
+	for i := range pageReplies {
+		nestedReplies := replies[pageReplies[i].Id]
+		if len(nestedReplies) > MAX_THREAD_REPLIES {
+			nestedReplies = nestedReplies[:MAX_THREAD_REPLIES]
+		}
+		replyThread, err := getCommentThreadObj(db, &pageReplies[i], nestedReplies, replies, authToken)
+		if err != nil {
+			return nil, err
+		}
+		thread.Replies[i] = *replyThread
+	}
+	allReplies := replies[comment.Id]
+	if len(pageReplies) > 0 {
+		lastReplyId := pageReplies[len(pageReplies)-1].Id
+		thread.HasMoreReplies = allReplies[len(allReplies)-1].Id != lastReplyId
+	} else {
+		thread.HasMoreReplies = len(allReplies) == 0 && commentObj.RepliesCount > 0
+	}
+	return thread, nil
+}
+
+// Removes the tombstones of deleted comments that no longer have replies,
+// starting from the given comment and going up its thread.
+func pruneDeletedComments(tx *sql.Tx, commentId string) error {
+	for len(commentId) > 0 {
+		parentId, isDeleted, repliesCount := "", false, 0
+		err := tx.QueryRow(
+			`SELECT comment_id, is_deleted, (
+				SELECT COUNT(*) FROM comments AS replies WHERE replies.comment_id=comments.id
+			) FROM comments WHERE id=$1;`,
+			commentId,
+		).Scan(&parentId, &isDeleted, &repliesCount)
+		if err != nil {
+			return err
+		}
+		if !isDeleted || repliesCount > 0 {
+			return nil
+		}
+		err = deleteComments(tx, "SELECT $1::VARCHAR(36)", commentId)
+		if err != nil {
+			return err
+		}
+		commentId = parentId
+	}
+	return nil
+}
+
+// Deletes the comments whose ids are selected by the given query along with
+// their likes, edit history, mentions and notifications.
+func deleteComments(tx *sql.Tx, idsQuery string, args ...any) error {
+	_, err := tx.Exec(
+		"DELETE FROM comments_likes WHERE comment_id IN ("+idsQuery+");",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM mentions WHERE comment_id IN ("+idsQuery+");",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM notifications WHERE comment_id IN ("+idsQuery+");",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM comment_revisions WHERE comment_id IN ("+idsQuery+");",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM comments WHERE id IN ("+idsQuery+");", args...)
+	return err
+}
+
+// Turns the comments whose ids are selected by the given query into
+// tombstones, which keep their replies in their threads, and removes
+// their likes, edit history, mentions and notifications.
+func tombstoneComments(tx *sql.Tx, idsQuery string, args ...any) error {
+	_, err := tx.Exec(
+		"DELETE FROM comments_likes WHERE comment_id IN ("+idsQuery+");",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM mentions WHERE comment_id IN ("+idsQuery+");",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM notifications WHERE comment_id IN ("+idsQuery+");",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM comment_revisions WHERE comment_id IN ("+idsQuery+");",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE comments SET is_deleted=TRUE, text='' WHERE id IN ("+idsQuery+");",
+		args...,
+	)
+	return err
+}
+
+// Creates the response object of a comment's verse anchor.
+// Comments made on the whole poem don't have an anchor.
+func getCommentAnchor(comment *db_models.Comment) *response_models.CommentAnchor {
+	if comment.VerseIndex < 0 {
+		return nil
+	}
+	return &response_models.CommentAnchor{
+		VerseIndex: comment.VerseIndex,
+		RangeStart: comment.RangeStart,
+		RangeEnd:   comment.RangeEnd,
+		Text:       comment.AnchorText,
+		IsOrphaned: comment.IsOrphaned,
+	}
+}
+
+// Creates the response object of a comment.
+func getCommentObj(
+	db *sqlx.DB,
+	comment *db_models.Comment,
+	authToken *utils.AuthToken,
+) (*response_models.Comment, error) {
+	// tombstones can outlive their authors
+	user := &db_models.User{}
+	if !comment.IsDeleted && comment.UserId.Valid {
+		err := db.Get(user, "SELECT * FROM users WHERE id=$1;", comment.UserId.String)
+		if err != nil {
+			return nil, errors.New("Failed to find comment creator.")
+		}
+	}
+	repliesCount := 0
+	err := db.Get(
+		&repliesCount,
+		"SELECT COUNT(*) AS replies_count FROM comments WHERE comment_id=$1;",
+		comment.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	likesCount := 0
+	err = db.Get(
+		&likesCount,
+		"SELECT COUNT(*) AS likes_count FROM comments_likes WHERE comment_id=$1;",
+		comment.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	isLiked := false
+	if authToken != nil {
+		userLikesCount := 0
+		err = db.Get(
+			&userLikesCount,
+			"SELECT COUNT(*) FROM comments_likes WHERE comment_id=$1 AND user_id=$2;",
+			comment.Id,
+			authToken.UserId,
+		)
+		if err != nil {
+			return nil, err
+		}
+		isLiked = userLikesCount > 0
+	}
+	mentions, err := getCommentMentions(db, comment.Id)
+	if err != nil {
+		return nil, err
+	}
+	commentObj := &response_models.Comment{
+		Id: comment.Id,
+		User: response_models.UserMin{
+			Id:             user.Id,
+			Name:           user.Name,
+			Handle:         user.Handle,
+			ProfilePhotoId: user.ProfilePhotoId,
+		},
+		CreatedOn:    comment.CreatedOn.UTC().Format(time.RFC3339),
+		Text:         comment.Text,
+		PoemId:       comment.PoemId,
+		RepliesCount: repliesCount,
+		ReplyTo:      comment.CommentId,
+		Anchor:       getCommentAnchor(comment),
+		Depth:        comment.Depth,
+		IsDeleted:    comment.IsDeleted,
+		UpdatedOn:    comment.UpdatedOn.UTC().Format(time.RFC3339),
+		IsEdited:     comment.UpdatedOn.After(comment.CreatedOn),
+		LikesCount:   likesCount,
+		IsLiked:      isLiked,
+		Mentions:     mentions,
+	}
+	if comment.IsDeleted {
+		// tombstones don't reveal their authors
+		commentObj.User = response_models.UserMin{}
+	}
+	return commentObj, nil
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Retrieves the previous versions of a comment.
+func GetCommentRevisions(c *gin.Context) {
+	commentId := c.Query("id")
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	comment := &db_models.Comment{}
+	err = db.Get(
+		comment,
+		"SELECT * FROM comments WHERE id=$1 AND is_deleted=FALSE;",
+		commentId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment."})
+		return
+	}
+	revisions := []db_models.CommentRevision{}
+	err = db.Select(
+		&revisions,
+		"SELECT * FROM comment_revisions WHERE comment_id=$1;",
+		commentId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].CreatedOn.After(revisions[j].CreatedOn)
+	})
+	pageRevisions, err := utils.ExtractPage(revisions, *pageSpec)
+	pageRevisionsObjs := make([]response_models.CommentRevision, len(pageRevisions))
+	for i, pageRevision := range pageRevisions {
+		pageRevisionsObjs[i] = response_models.CommentRevision{
+			Id:        pageRevision.Id,
+			CommentId: pageRevision.CommentId,
+			Text:      pageRevision.Text,
+			CreatedOn: pageRevision.CreatedOn.UTC().Format(time.RFC3339),
+		}
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageRevisionsObjs,
+		},
+	)
+}
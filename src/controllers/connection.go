@@ -5,8 +5,11 @@ import (
 	"time"
 
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/notifications"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/timelines"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -66,6 +69,7 @@ func GetFollowers(c *gin.Context) {
 		pageFollowersObjs[i] = response_models.UserMin{
 			Id:             user.Id,
 			Name:           user.Name,
+			Handle:         user.Handle,
 			ProfilePhotoId: user.ProfilePhotoId,
 			IsFollowing:    isFollowingUser,
 		}
@@ -133,6 +137,7 @@ func GetFollowings(c *gin.Context) {
 		pageFollowingsObjs[i] = response_models.UserMin{
 			Id:             user.Id,
 			Name:           user.Name,
+			Handle:         user.Handle,
 			ProfilePhotoId: user.ProfilePhotoId,
 			IsFollowing:    isFollowingUser,
 		}
@@ -191,6 +196,22 @@ func ChangeConnection(c *gin.Context) {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
+		err = timelines.RemoveFollowing(tx, jsonBody.UserId, jsonBody.FollowId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = notifications.Remove(
+			tx,
+			db_models.NotificationTypeFollow,
+			jsonBody.FollowId,
+			jsonBody.UserId,
+			"",
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
 		err = tx.Commit()
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -205,6 +226,15 @@ func ChangeConnection(c *gin.Context) {
 		)
 	} else {
 		// userFollowing doesn't exist -> create connection
+		hasBlock, err := isBlocked(db, jsonBody.UserId, jsonBody.FollowId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if hasBlock {
+			c.JSON(200, gin.H{"success": false, "message": "You cannot follow this user."})
+			return
+		}
 		currentTime := time.Now().UTC()
 		connectionId := uuid.New().String()
 		newUserFollowing := &db_models.UserFollowing{
@@ -230,6 +260,31 @@ func ChangeConnection(c *gin.Context) {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
+		err = timelines.AddFollowing(tx, jsonBody.UserId, jsonBody.FollowId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = notifications.Add(tx, db_models.Notification{
+			UserId:    jsonBody.FollowId,
+			ActorId:   jsonBody.UserId,
+			Type:      db_models.NotificationTypeFollow,
+			GroupKey:  notifications.GetGroupKey(db_models.NotificationTypeFollow, jsonBody.FollowId),
+			CreatedOn: currentTime,
+		})
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = events.Publish(tx, events.Event{
+			Type:     events.EventUserFollowed,
+			UserId:   jsonBody.FollowId,
+			AuthorId: jsonBody.UserId,
+		})
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
 		err = tx.Commit()
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
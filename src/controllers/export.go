@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"mime"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/exports"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// The maximum number of poems exported as an anthology.
+	MAX_EXPORT_POEMS = 1000
+)
+
+// Creates the exported form of a poem written by a given user.
+func getExportPoem(poem *db_models.Poem, user *db_models.User) (*exports.Poem, error) {
+	body, err := utils.ParsePoemBody(poem.Text)
+	if err != nil {
+		return nil, err
+	}
+	return &exports.Poem{
+		Id:          poem.Id,
+		Title:       poem.Title,
+		Author:      user.Name,
+		Language:    poem.Language,
+		PublishedOn: poem.PublishAt,
+		Body:        body,
+	}, nil
+}
+
+// Sends an exported file as an attachment.
+func sendExportFile(c *gin.Context, file *exports.File) {
+	c.Header(
+		"Content-Disposition",
+		mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}),
+	)
+	c.Data(200, file.ContentType, file.Data)
+}
+
+// Exports a given poem as a Markdown, plain text, EPUB or PDF file.
+func ExportPoem(c *gin.Context) {
+	poemId := c.Query("id")
+	format := c.DefaultQuery("format", exports.FORMAT_MARKDOWN)
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	poem := &db_models.Poem{}
+	err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", poemId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	if poem.Status != db_models.PoemStatusPublished &&
+		(authToken == nil || authToken.UserId != poem.UserId) {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", poem.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem creator."})
+		return
+	}
+	exportPoem, err := getExportPoem(poem, user)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	file, err := exports.ExportPoem(*exportPoem, format)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sendExportFile(c, file)
+}
+
+// Exports all of a given user's published poems, from the oldest, as an
+// EPUB book with a table of contents or as a PDF document. A user's
+// export of their own poems also has their drafts and scheduled poems.
+func ExportUserPoems(c *gin.Context) {
+	userId := c.Query("id")
+	format := c.DefaultQuery("format", exports.FORMAT_EPUB)
+	if format != exports.FORMAT_EPUB && format != exports.FORMAT_PDF {
+		c.JSON(200, gin.H{"success": false, "message": "Unsupported export format."})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", userId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+		return
+	}
+	isOwner := authToken != nil && authToken.UserId == user.Id
+	poems := []db_models.Poem{}
+	err = db.Select(
+		&poems,
+		`SELECT * FROM poems WHERE user_id=$1 AND (status='published' OR $2)
+		ORDER BY publish_at, id
+		LIMIT $3;`,
+		user.Id,
+		isOwner,
+		MAX_EXPORT_POEMS+1,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if len(poems) > MAX_EXPORT_POEMS {
+		c.JSON(200, gin.H{"success": false, "message": "Too many poems to export."})
+		return
+	}
+	anthology := exports.Anthology{
+		Id:     user.Id,
+		Title:  "Poems by " + user.Name,
+		Author: user.Name,
+		Poems:  make([]exports.Poem, len(poems)),
+	}
+	for i := range poems {
+		exportPoem, err := getExportPoem(&poems[i], user)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		anthology.Poems[i] = *exportPoem
+	}
+	file, err := exports.ExportAnthology(anthology, format)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sendExportFile(c, file)
+}
+
+// Exports the published poems of a given collection, in their order in the
+// collection, as an EPUB book with a table of contents or as a PDF document.
+func ExportCollection(c *gin.Context) {
+	collectionId := c.Query("id")
+	format := c.DefaultQuery("format", exports.FORMAT_EPUB)
+	if format != exports.FORMAT_EPUB && format != exports.FORMAT_PDF {
+		c.JSON(200, gin.H{"success": false, "message": "Unsupported export format."})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	collection := &db_models.Collection{}
+	err = db.Get(collection, "SELECT * FROM collections WHERE id=$1;", collectionId)
+	if err != nil || !canViewCollection(collection, authToken) {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection."})
+		return
+	}
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", collection.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find collection creator."})
+		return
+	}
+	poems := []db_models.Poem{}
+	err = db.Select(
+		&poems,
+		`SELECT poems.* FROM poems
+		INNER JOIN collections_poems ON collections_poems.poem_id=poems.id
+		WHERE collections_poems.collection_id=$1 AND poems.status='published'
+		ORDER BY collections_poems.position
+		LIMIT $2;`,
+		collection.Id,
+		MAX_EXPORT_POEMS+1,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if len(poems) > MAX_EXPORT_POEMS {
+		c.JSON(200, gin.H{"success": false, "message": "Too many poems to export."})
+		return
+	}
+	anthology := exports.Anthology{
+		Id:     collection.Id,
+		Title:  collection.Title,
+		Author: user.Name,
+		Poems:  make([]exports.Poem, len(poems)),
+	}
+	// collections can have poems written by other users
+	poemsUsers := map[string]*db_models.User{user.Id: user}
+	for i := range poems {
+		poemUser, ok := poemsUsers[poems[i].UserId]
+		if !ok {
+			poemUser = &db_models.User{}
+			err = db.Get(poemUser, "SELECT * FROM users WHERE id=$1;", poems[i].UserId)
+			if err != nil {
+				c.JSON(200, gin.H{"success": false, "message": "Failed to find poem creator."})
+				return
+			}
+			poemsUsers[poemUser.Id] = poemUser
+		}
+		exportPoem, err := getExportPoem(&poems[i], poemUser)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		anthology.Poems[i] = *exportPoem
+	}
+	file, err := exports.ExportAnthology(anthology, format)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sendExportFile(c, file)
+}
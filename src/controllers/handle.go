@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Checks if a handle isn't used by another user. Old handles are kept
+// for their previous owners while they still redirect to the new handles.
+func isHandleAvailable(db *sqlx.DB, handle, userId string, currentTime time.Time) (bool, error) {
+	usersCount := 0
+	err := db.Get(
+		&usersCount,
+		"SELECT COUNT(*) FROM users WHERE lower(handle)=lower($1) AND id<>$2;",
+		handle,
+		userId,
+	)
+	if err != nil {
+		return false, err
+	}
+	changesCount := 0
+	err = db.Get(
+		&changesCount,
+		`SELECT COUNT(*) FROM handle_changes
+		WHERE lower(old_handle)=lower($1) AND user_id<>$2 AND created_on>$3;`,
+		handle,
+		userId,
+		currentTime.Add(-utils.HandleRedirectDuration),
+	)
+	if err != nil {
+		return false, err
+	}
+	return usersCount == 0 && changesCount == 0, nil
+}
+
+// Retrieves information about the user with a given handle.
+// Recently changed handles redirect to the user's current handle.
+func GetUserByHandle(c *gin.Context) {
+	handle := c.Param("handle")
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE lower(handle)=lower($1);", handle)
+	if err != nil {
+		handleChange := &db_models.HandleChange{}
+		err = db.Get(
+			handleChange,
+			`SELECT * FROM handle_changes
+			WHERE lower(old_handle)=lower($1) AND created_on>$2
+			ORDER BY created_on DESC LIMIT 1;`,
+			handle,
+			time.Now().UTC().Add(-utils.HandleRedirectDuration),
+		)
+		if err == nil {
+			err = db.Get(user, "SELECT * FROM users WHERE id=$1;", handleChange.UserId)
+		}
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+			return
+		}
+		location := "/api/v1/users/by-handle/" + user.Handle
+		if len(c.Request.URL.RawQuery) > 0 {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusFound, location)
+		return
+	}
+	userData, err := getUserData(db, user, authToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    userData,
+		},
+	)
+}
+
+// Changes a user's handle.
+func UpdateUserHandle(c *gin.Context) {
+	var jsonBody request_models.UserHandleUpdateForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	err = utils.ValidateHandle(jsonBody.Handle)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	currentTime := time.Now().UTC()
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", jsonBody.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+		return
+	}
+	if user.Handle == jsonBody.Handle {
+		c.JSON(200, gin.H{"success": false, "message": "Handle has not changed."})
+		return
+	}
+	lastChange := &db_models.HandleChange{}
+	err = db.Get(
+		lastChange,
+		`SELECT * FROM handle_changes WHERE user_id=$1
+		ORDER BY created_on DESC LIMIT 1;`,
+		jsonBody.UserId,
+	)
+	if err == nil && currentTime.Sub(lastChange.CreatedOn) < utils.HandleChangeCooldown {
+		c.JSON(200, gin.H{"success": false, "message": "Handle was changed too recently."})
+		return
+	}
+	isAvailable, err := isHandleAvailable(db, jsonBody.Handle, jsonBody.UserId, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if !isAvailable {
+		c.JSON(200, gin.H{"success": false, "message": "Handle is already taken."})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// changing only the letter case of a handle doesn't need a redirect
+	if !strings.EqualFold(user.Handle, jsonBody.Handle) {
+		_, err = tx.Exec(
+			`INSERT INTO handle_changes (id, user_id, old_handle, new_handle, created_on)
+			VALUES ($1, $2, $3, $4, $5);`,
+			uuid.New().String(),
+			user.Id,
+			user.Handle,
+			jsonBody.Handle,
+			currentTime,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
+	_, err = tx.Exec(
+		"UPDATE users SET updated_on=$1, handle=$2 WHERE id=$3;",
+		currentTime,
+		jsonBody.Handle,
+		user.Id,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	user.Handle = jsonBody.Handle
+	search.SyncUser(user)
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"handle":    jsonBody.Handle,
+				"updatedOn": currentTime.Format(time.RFC3339),
+			},
+		},
+	)
+}
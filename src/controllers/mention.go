@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Finds the ids of the users with the given handles who can be mentioned
+// by an author. Handles that don't belong to any user are left out, as
+// are users who have a block with the author.
+func resolveHandles(tx *sql.Tx, authorId string, mentions []utils.Mention) (map[string]string, error) {
+	userIds := make(map[string]string)
+	for _, mention := range mentions {
+		handle := strings.ToLower(mention.Handle)
+		if _, ok := userIds[handle]; ok {
+			continue
+		}
+		userId := ""
+		err := tx.QueryRow(
+			"SELECT id FROM users WHERE lower(handle)=$1 AND "+getUnblockedUserCondition("id", "$2")+";",
+			handle,
+			authorId,
+		).Scan(&userId)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		userIds[handle] = userId
+	}
+	return userIds, nil
+}
+
+// Saves the mentions of users in a text of a poem or comment.
+func insertMentions(
+	tx *sql.Tx,
+	mention db_models.Mention,
+	mentions []utils.Mention,
+) error {
+	userIds, err := resolveHandles(tx, mention.AuthorId, mentions)
+	if err != nil {
+		return err
+	}
+	for _, textMention := range mentions {
+		userId := userIds[strings.ToLower(textMention.Handle)]
+		if len(userId) == 0 {
+			continue
+		}
+		_, err = tx.Exec(
+			`INSERT INTO mentions (
+				id, user_id, author_id, poem_id, comment_id,
+				verse_index, "offset", length, created_on
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+			uuid.New().String(),
+			userId,
+			mention.AuthorId,
+			mention.PoemId,
+			mention.CommentId,
+			mention.VerseIndex,
+			textMention.Offset,
+			textMention.Length,
+			mention.CreatedOn,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replaces the mentions of users in a poem's verses.
+func setPoemMentions(tx *sql.Tx, poemId, authorId, text string, currentTime time.Time) error {
+	verses, err := utils.GetPoemVerses(text)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM mentions WHERE poem_id=$1 AND comment_id='';",
+		poemId,
+	)
+	if err != nil {
+		return err
+	}
+	for i, verse := range verses {
+		err = insertMentions(
+			tx,
+			db_models.Mention{
+				AuthorId:   authorId,
+				PoemId:     poemId,
+				VerseIndex: i,
+				CreatedOn:  currentTime,
+			},
+			utils.ExtractMentions(verse),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replaces the mentions of users in a comment.
+func setCommentMentions(tx *sql.Tx, comment *db_models.Comment, currentTime time.Time) error {
+	_, err := tx.Exec("DELETE FROM mentions WHERE comment_id=$1;", comment.Id)
+	if err != nil {
+		return err
+	}
+	return insertMentions(
+		tx,
+		db_models.Mention{
+			AuthorId:   comment.UserId.String,
+			PoemId:     comment.PoemId,
+			CommentId:  comment.Id,
+			VerseIndex: -1,
+			CreatedOn:  currentTime,
+		},
+		utils.ExtractMentions(comment.Text),
+	)
+}
+
+// Retrieves the mentions of users in a poem's verses.
+func getPoemMentions(db *sqlx.DB, poemId string) ([]response_models.VerseMention, error) {
+	mentions := []db_models.Mention{}
+	err := db.Select(
+		&mentions,
+		`SELECT * FROM mentions WHERE poem_id=$1 AND comment_id=''
+		ORDER BY verse_index, "offset";`,
+		poemId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	mentionsObjs := make([]response_models.VerseMention, len(mentions))
+	for i, mention := range mentions {
+		mentionsObjs[i] = response_models.VerseMention{
+			VerseIndex: mention.VerseIndex,
+			UserId:     mention.UserId,
+			Offset:     mention.Offset,
+			Length:     mention.Length,
+		}
+	}
+	return mentionsObjs, nil
+}
+
+// Retrieves the mentions of users in a comment.
+func getCommentMentions(db *sqlx.DB, commentId string) ([]response_models.Mention, error) {
+	mentions := []db_models.Mention{}
+	err := db.Select(
+		&mentions,
+		`SELECT * FROM mentions WHERE comment_id=$1 ORDER BY "offset";`,
+		commentId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	mentionsObjs := make([]response_models.Mention, len(mentions))
+	for i, mention := range mentions {
+		mentionsObjs[i] = response_models.Mention{
+			UserId: mention.UserId,
+			Offset: mention.Offset,
+			Length: mention.Length,
+		}
+	}
+	return mentionsObjs, nil
+}
+
+// Retrieves the poems and comments the current user was mentioned in.
+func GetMentions(c *gin.Context) {
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// a text mentioning the user more than once is listed once
+	mentions := []db_models.Mention{}
+	err = db.Select(
+		&mentions,
+		`SELECT DISTINCT ON (mentions.poem_id, mentions.comment_id) mentions.*
+		FROM mentions
+		INNER JOIN poems ON poems.id=mentions.poem_id
+		WHERE mentions.user_id=$1 AND poems.status='published'
+		ORDER BY mentions.poem_id, mentions.comment_id, mentions.verse_index, mentions."offset";`,
+		authToken.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(mentions, func(i, j int) bool {
+		return mentions[i].CreatedOn.After(mentions[j].CreatedOn)
+	})
+	pageMentions, err := utils.ExtractPage(mentions, *pageSpec)
+	pageMentionsObjs := make([]response_models.UserMention, len(pageMentions))
+	for i, pageMention := range pageMentions {
+		author := &db_models.User{}
+		err = db.Get(author, "SELECT * FROM users WHERE id=$1;", pageMention.AuthorId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": "Failed to find author."})
+			return
+		}
+		pageMentionsObjs[i] = response_models.UserMention{
+			Id: pageMention.Id,
+			Author: response_models.UserMin{
+				Id:             author.Id,
+				Name:           author.Name,
+				Handle:         author.Handle,
+				ProfilePhotoId: author.ProfilePhotoId,
+			},
+			PoemId:    pageMention.PoemId,
+			CommentId: pageMention.CommentId,
+			CreatedOn: pageMention.CreatedOn.UTC().Format(time.RFC3339),
+		}
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageMentionsObjs,
+		},
+	)
+}
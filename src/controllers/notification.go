@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// The maximum number of actors listed in an aggregated notification.
+const MAX_NOTIFICATION_ACTORS = 3
+
+// Represents notifications with the same group key and read status.
+type notificationGroup struct {
+	Head          db_models.Notification
+	Notifications []db_models.Notification
+}
+
+// Groups a user's notifications with the same group key and read status,
+// with the most recent groups first.
+func groupNotifications(notifications []db_models.Notification) []notificationGroup {
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedOn.After(notifications[j].CreatedOn)
+	})
+	groups := []notificationGroup{}
+	groupsIndexes := make(map[string]int)
+	for _, notification := range notifications {
+		key := fmt.Sprintf("%s:%t", notification.GroupKey, notification.IsRead)
+		if i, ok := groupsIndexes[key]; ok {
+			groups[i].Notifications = append(groups[i].Notifications, notification)
+			continue
+		}
+		groupsIndexes[key] = len(groups)
+		groups = append(groups, notificationGroup{
+			Head:          notification,
+			Notifications: []db_models.Notification{notification},
+		})
+	}
+	return groups
+}
+
+// Creates the text of an aggregated notification.
+func getNotificationMessage(notificationType string, actors []response_models.UserMin, actorsCount int) string {
+	actorsText := ""
+	if actorsCount == 1 {
+		actorsText = actors[0].Name
+	} else if actorsCount == 2 && len(actors) > 1 {
+		actorsText = actors[0].Name + " and " + actors[1].Name
+	} else if actorsCount == 2 {
+		actorsText = actors[0].Name + " and 1 other"
+	} else {
+		actorsText = fmt.Sprintf("%s and %d others", actors[0].Name, actorsCount-1)
+	}
+	switch notificationType {
+	case db_models.NotificationTypeReaction:
+		return actorsText + " liked your poem."
+	case db_models.NotificationTypeComment:
+		return actorsText + " commented on your poem."
+	case db_models.NotificationTypeReply:
+		return actorsText + " replied to your comment."
+	case db_models.NotificationTypeFollow:
+		return actorsText + " started following you."
+	default:
+		return actorsText + " mentioned you."
+	}
+}
+
+// Creates the response object of a group of notifications.
+func getNotificationObj(db *sqlx.DB, group *notificationGroup) (*response_models.Notification, error) {
+	actors := []response_models.UserMin{}
+	seenActors := make(map[string]bool)
+	for _, notification := range group.Notifications {
+		if seenActors[notification.ActorId] {
+			continue
+		}
+		seenActors[notification.ActorId] = true
+		if len(actors) >= MAX_NOTIFICATION_ACTORS {
+			continue
+		}
+		user := &db_models.User{}
+		err := db.Get(user, "SELECT * FROM users WHERE id=$1;", notification.ActorId)
+		if err != nil {
+			return nil, err
+		}
+		actors = append(actors, response_models.UserMin{
+			Id:             user.Id,
+			Name:           user.Name,
+			Handle:         user.Handle,
+			ProfilePhotoId: user.ProfilePhotoId,
+		})
+	}
+	return &response_models.Notification{
+		Id:          group.Head.Id,
+		Type:        group.Head.Type,
+		Actors:      actors,
+		ActorsCount: len(seenActors),
+		PoemId:      group.Head.PoemId,
+		CommentId:   group.Head.CommentId,
+		Message:     getNotificationMessage(group.Head.Type, actors, len(seenActors)),
+		IsRead:      group.Head.IsRead,
+		CreatedOn:   group.Head.CreatedOn.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// Retrieves the current user's notifications, aggregating
+// notifications about the same poem, comment or action.
+func GetNotifications(c *gin.Context) {
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	notifications := []db_models.Notification{}
+	err = db.Select(
+		&notifications,
+		"SELECT * FROM notifications WHERE user_id=$1;",
+		authToken.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	groups := groupNotifications(notifications)
+	groupsIndexes := make(map[string]int, len(groups))
+	heads := make([]db_models.Notification, len(groups))
+	unreadCount := 0
+	for i, group := range groups {
+		groupsIndexes[group.Head.Id] = i
+		heads[i] = group.Head
+		if !group.Head.IsRead {
+			unreadCount++
+		}
+	}
+	pageHeads, err := utils.ExtractPage(heads, *pageSpec)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	pageNotificationsObjs := make([]response_models.Notification, len(pageHeads))
+	for i, pageHead := range pageHeads {
+		notificationObj, err := getNotificationObj(db, &groups[groupsIndexes[pageHead.Id]])
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pageNotificationsObjs[i] = *notificationObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"notifications": pageNotificationsObjs,
+				"unreadCount":   unreadCount,
+			},
+		},
+	)
+}
+
+// Retrieves the number of unread notifications of the current user.
+func GetUnreadNotificationsCount(c *gin.Context) {
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	unreadCount := 0
+	err = db.Get(
+		&unreadCount,
+		`SELECT COUNT(DISTINCT group_key) FROM notifications
+		WHERE user_id=$1 AND is_read=FALSE;`,
+		authToken.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{"unreadCount": unreadCount},
+		},
+	)
+}
+
+// Marks the given notifications of the current user as read, including the
+// older notifications aggregated with them. All notifications are marked as
+// read when none are given.
+func MarkNotificationsRead(c *gin.Context) {
+	var jsonBody request_models.NotificationsReadForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if len(jsonBody.NotificationIds) == 0 {
+		_, err = tx.Exec(
+			"UPDATE notifications SET is_read=TRUE WHERE user_id=$1 AND is_read=FALSE;",
+			jsonBody.UserId,
+		)
+	} else {
+		_, err = tx.Exec(
+			`UPDATE notifications SET is_read=TRUE
+			FROM notifications AS heads
+			WHERE notifications.user_id=$1 AND notifications.is_read=FALSE
+				AND heads.user_id=$1 AND heads.id=ANY($2)
+				AND notifications.group_key=heads.group_key
+				AND notifications.created_on<=heads.created_on;`,
+			jsonBody.UserId,
+			pq.Array(jsonBody.NotificationIds),
+		)
+	}
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{},
+		},
+	)
+}
@@ -1,22 +1,27 @@
 package controllers
 
 import (
-	"encoding/json"
-	"math"
+	"database/sql"
+	"errors"
+	"fmt"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/notifications"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/timelines"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 const (
-	// The maximum number of poems for the explore page.
+	// The maximum number of poems in a page of the explore feed.
 	MAX_EXPLORE_PAGE_POEMS = 255
 )
 
@@ -35,43 +40,12 @@ func GetPoem(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
 		return
 	}
-	user := &db_models.User{}
-	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", poem.UserId)
-	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment creator."})
-		return
-	}
-	comments := []db_models.Comment{}
-	err = db.Select(
-		&comments,
-		"SELECT * FROM comments WHERE poem_id=$1 AND comment_id=$2",
-		poemId,
-		"",
-	)
-	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": err.Error()})
-		return
-	}
-	likesCount := 0
-	err = db.Select(
-		&likesCount,
-		"SELECT COUNT(*) AS likes_count FROM poems_likes WHERE poem_id=$1;",
-		poemId,
-	)
-	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+	if poem.Status != db_models.PoemStatusPublished &&
+		(authToken == nil || authToken.UserId != poem.UserId) {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
 		return
 	}
-	poemLike := &db_models.PoemLike{}
-	err = db.Get(
-		poemLike,
-		"SELECT * FROM poems_likes WHERE poem_id=$1 AND user_id=$2;",
-		poemId,
-		authToken.UserId,
-	)
-	isLiked := err != nil
-	verses := []string{}
-	err = json.Unmarshal([]byte(poem.Text), &verses)
+	poemObj, err := getPoemObj(db, poem, authToken)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
@@ -80,24 +54,27 @@ func GetPoem(c *gin.Context) {
 		200,
 		gin.H{
 			"success": true,
-			"data": gin.H{
-				"id": poem.Id,
-				"user": gin.H{
-					"id":             user.Id,
-					"name":           user.Name,
-					"profilePhotoId": user.ProfilePhotoId,
-				},
-				"title":         poem.Title,
-				"publishedOn":   poem.CreatedOn.UTC().Format(time.RFC3339),
-				"verses":        verses,
-				"commentsCount": len(comments),
-				"likesCount":    likesCount,
-				"isLiked":       isLiked,
-			},
+			"data":    poemObj,
 		},
 	)
 }
 
+// Retrieves the body of a poem from a form, which has either a structured
+// body or the flat list of verses sent by older clients.
+func getPoemFormBody(verses []string, body *utils.PoemBody) (*utils.PoemBody, error) {
+	if body == nil {
+		if len(verses) < 1 {
+			return nil, errors.New("At least 1 verse is needed.")
+		}
+		body = utils.NewPoemBody(verses)
+	}
+	err := body.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
 // Creates a new poem.
 func AddPoem(c *gin.Context) {
 	var jsonBody request_models.PoemAddForm
@@ -121,7 +98,12 @@ func AddPoem(c *gin.Context) {
 		return
 	}
 	currentTime := time.Now().UTC()
-	versesTxt, err := json.Marshal(jsonBody.Verses)
+	status, publishAt, err := getPoemSchedule(jsonBody.Status, jsonBody.PublishAt, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	language, err := utils.GetSearchLanguage(jsonBody.Language)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
@@ -130,15 +112,20 @@ func AddPoem(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": "Title is too long."})
 		return
 	}
-	if len(jsonBody.Verses) < 1 {
-		c.JSON(200, gin.H{"success": false, "message": "At least 1 verse is needed."})
+	body, err := getPoemFormBody(jsonBody.Verses, jsonBody.Body)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	for i := 0; i < len(jsonBody.Verses); i++ {
-		if len(strings.Trim(jsonBody.Verses[i], " ")) < 1 {
-			c.JSON(200, gin.H{"success": false, "message": "Some verses are too short."})
-			return
-		}
+	bodyTxt, err := body.Encode()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	tags, err := utils.GetPoemTags(jsonBody.Tags, body.Verses())
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
 	}
 	poemId := uuid.New().String()
 	poem := &db_models.Poem{
@@ -147,7 +134,10 @@ func AddPoem(c *gin.Context) {
 		UpdatedOn: currentTime,
 		UserId:    authToken.UserId,
 		Title:     jsonBody.Title,
-		Text:      string(versesTxt),
+		Text:      bodyTxt,
+		Status:    status,
+		PublishAt: publishAt,
+		Language:  language,
 	}
 	tx, err := db.Begin()
 	if err != nil {
@@ -155,24 +145,61 @@ func AddPoem(c *gin.Context) {
 		return
 	}
 	_, err = tx.Exec(
-		`INSERT INTO poems (id, created_on, updated_on, user_id, title, text)
-		VALUES ($1, $2, $3, $4, $5, $6);`,
+		`INSERT INTO poems (
+			id, created_on, updated_on, user_id, title, text, status, publish_at, language
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
 		poem.Id,
 		poem.CreatedOn,
 		poem.UpdatedOn,
 		poem.UserId,
 		poem.Title,
 		poem.Text,
+		poem.Status,
+		poem.PublishAt,
+		poem.Language,
 	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	err = setPoemTags(tx, poem.Id, tags, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = setPoemMentions(tx, poem.Id, poem.UserId, poem.Text, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if poem.Status == db_models.PoemStatusPublished {
+		err = notifications.AddMentions(tx, poem.UserId, poem.Id, "", currentTime)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = timelines.AddPoem(tx, poem.Id, poem.UserId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = events.Publish(tx, events.Event{
+			Type:     events.EventPoemCreated,
+			AuthorId: poem.UserId,
+			PoemId:   poem.Id,
+		})
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
 	err = tx.Commit()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	search.SyncPoem(poem)
 	c.JSON(
 		200,
 		gin.H{
@@ -180,6 +207,9 @@ func AddPoem(c *gin.Context) {
 			"data": gin.H{
 				"id":           poemId,
 				"createdOn":    currentTime.UTC().Format(time.RFC3339),
+				"status":       poem.Status,
+				"publishAt":    poem.PublishAt.Format(time.RFC3339),
+				"tags":         tags,
 				"repliesCount": 0,
 				"likesCount":   0,
 			},
@@ -220,48 +250,107 @@ func UpdatePoem(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": "You are not allowed to edit this poem."})
 		return
 	}
-	versesTxt, err := json.Marshal(jsonBody.Verses)
-	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": err.Error()})
-		return
+	language := poem.Language
+	if len(jsonBody.Language) > 0 {
+		language, err = utils.GetSearchLanguage(jsonBody.Language)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
 	}
 	if len(jsonBody.Title) > 256 {
 		c.JSON(200, gin.H{"success": false, "message": "Title is too long."})
 		return
 	}
-	if len(jsonBody.Verses) < 1 {
-		c.JSON(200, gin.H{"success": false, "message": "At least 1 verse is needed."})
+	body, err := getPoemFormBody(jsonBody.Verses, jsonBody.Body)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	for i := 0; i < len(jsonBody.Verses); i++ {
-		if len(strings.Trim(jsonBody.Verses[i], " ")) < 1 {
-			c.JSON(200, gin.H{"success": false, "message": "Some verses are too short."})
-			return
-		}
+	bodyTxt, err := body.Encode()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	tags, err := utils.GetPoemTags(jsonBody.Tags, body.Verses())
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
 	}
 	tx, err := db.Begin()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	// keep the poem's current version as a revision
+	_, err = tx.Exec(
+		`INSERT INTO poem_revisions (id, poem_id, user_id, title, text, created_on)
+		VALUES ($1, $2, $3, $4, $5, $6);`,
+		uuid.New().String(),
+		poem.Id,
+		poem.UserId,
+		poem.Title,
+		poem.Text,
+		poem.UpdatedOn,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	_, err = tx.Exec(
 		`UPDATE poems SET
-			updated_on=$1, title=$2, text=$3
-			WHERE id=$4;`,
+			updated_on=$1, title=$2, text=$3, language=$4
+			WHERE id=$5;`,
 		currentTime,
 		jsonBody.Title,
-		string(versesTxt),
+		bodyTxt,
+		language,
 		jsonBody.PoemId,
 	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	err = remapPoemAnnotations(db, tx, poem.Id, poem.Text, bodyTxt)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = setPoemTags(tx, jsonBody.PoemId, tags, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = setPoemMentions(tx, poem.Id, poem.UserId, bodyTxt, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if poem.Status == db_models.PoemStatusPublished {
+		err = notifications.AddMentions(tx, poem.UserId, poem.Id, "", currentTime)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = events.Publish(tx, events.Event{
+			Type:     events.EventPoemUpdated,
+			AuthorId: poem.UserId,
+			PoemId:   poem.Id,
+		})
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
 	err = tx.Commit()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	poem.Title = jsonBody.Title
+	poem.Text = bodyTxt
+	poem.Language = language
+	search.SyncPoem(poem)
 	c.JSON(
 		200,
 		gin.H{
@@ -296,7 +385,7 @@ func RemovePoem(c *gin.Context) {
 	poem := &db_models.Poem{}
 	err = db.Get(
 		poem,
-		"SELECT * FROM poem WHERE id=$1;",
+		"SELECT * FROM poems WHERE id=$1;",
 		jsonBody.PoemId,
 	)
 	if err != nil {
@@ -314,6 +403,7 @@ func RemovePoem(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	defer tx.Rollback()
 	_, err = tx.Exec(
 		"DELETE FROM poems_likes WHERE poem_id=$1;",
 		jsonBody.PoemId,
@@ -322,8 +412,68 @@ func RemovePoem(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	_, err = tx.Exec("DELETE FROM mentions WHERE poem_id=$1;", jsonBody.PoemId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec("DELETE FROM notifications WHERE poem_id=$1;", jsonBody.PoemId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = deleteComments(
+		tx,
+		"SELECT id FROM comments WHERE poem_id=$1",
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	_, err = tx.Exec(
-		"DELETE FROM comments WHERE poem_id=$1;",
+		"DELETE FROM poem_revisions WHERE poem_id=$1;",
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"DELETE FROM poems_tags WHERE poem_id=$1;",
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = removeCollectionsPoems(
+		tx,
+		"SELECT id FROM collections_poems WHERE poem_id=$1",
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"DELETE FROM bookmarks WHERE poem_id=$1;",
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"DELETE FROM timelines WHERE poem_id=$1;",
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		"DELETE FROM explore_rankings WHERE poem_id=$1;",
 		jsonBody.PoemId,
 	)
 	if err != nil {
@@ -343,6 +493,7 @@ func RemovePoem(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	search.Remove(search.DOCUMENT_POEM, jsonBody.PoemId)
 	c.JSON(
 		200,
 		gin.H{
@@ -352,7 +503,8 @@ func RemovePoem(c *gin.Context) {
 	)
 }
 
-// Toggles a user's reaction on a poem.
+// Sets, changes or removes a user's reaction on a poem.
+// Reacting again with the same reaction removes it.
 func ChangePoemReaction(c *gin.Context) {
 	var jsonBody request_models.PoemLikeForm
 	err := c.ShouldBindJSON(&jsonBody)
@@ -361,6 +513,10 @@ func ChangePoemReaction(c *gin.Context) {
 		return
 	}
 	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	db, err := utils.GetDBConnection()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -370,10 +526,18 @@ func ChangePoemReaction(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
 		return
 	}
+	reaction := jsonBody.Reaction
+	if len(reaction) == 0 {
+		reaction = utils.GetDefaultPoemReaction()
+	}
+	if !utils.IsValidPoemReaction(reaction) {
+		c.JSON(200, gin.H{"success": false, "message": "Invalid reaction."})
+		return
+	}
 	poem := &db_models.Poem{}
 	err = db.Get(
 		poem,
-		"SELECT * FROM poem WHERE id=$1;",
+		"SELECT * FROM poems WHERE id=$1 AND status='published';",
 		jsonBody.PoemId,
 	)
 	if err != nil {
@@ -387,8 +551,20 @@ func ChangePoemReaction(c *gin.Context) {
 		jsonBody.UserId,
 		jsonBody.PoemId,
 	)
-	if err == nil {
-		// poemLike exists -> remove like
+	// reactions can still be removed after a block
+	if err != nil || poemLike.Reaction != reaction {
+		hasBlock, err := isBlocked(db, jsonBody.UserId, poem.UserId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if hasBlock {
+			c.JSON(200, gin.H{"success": false, "message": "You cannot react to this poem."})
+			return
+		}
+	}
+	if err == nil && poemLike.Reaction == reaction {
+		// same reaction exists -> remove reaction
 		tx, err := db.Begin()
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -403,6 +579,46 @@ func ChangePoemReaction(c *gin.Context) {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
+		err = notifications.Remove(
+			tx,
+			db_models.NotificationTypeReaction,
+			poem.UserId,
+			jsonBody.UserId,
+			jsonBody.PoemId,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = tx.Commit()
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(
+			200,
+			gin.H{
+				"success": true,
+				"data":    gin.H{"status": false, "reaction": ""},
+			},
+		)
+	} else if err == nil {
+		// another reaction exists -> change reaction
+		tx, err := db.Begin()
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		_, err = tx.Exec(
+			"UPDATE poems_likes SET reaction=$1 WHERE user_id=$2 AND poem_id=$3;",
+			reaction,
+			jsonBody.UserId,
+			jsonBody.PoemId,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
 		err = tx.Commit()
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -412,17 +628,18 @@ func ChangePoemReaction(c *gin.Context) {
 			200,
 			gin.H{
 				"success": true,
-				"data":    gin.H{"status": false},
+				"data":    gin.H{"status": true, "reaction": reaction},
 			},
 		)
 	} else {
-		// poemLike doesn't exist -> create like
+		// reaction doesn't exist -> create reaction
 		currentTime := time.Now().UTC()
 		connectionId := uuid.New().String()
 		newPoemLike := &db_models.PoemLike{
 			Id:        connectionId,
 			UserId:    jsonBody.UserId,
 			PoemId:    jsonBody.PoemId,
+			Reaction:  reaction,
 			CreatedOn: currentTime,
 		}
 		tx, err := db.Begin()
@@ -431,17 +648,30 @@ func ChangePoemReaction(c *gin.Context) {
 			return
 		}
 		_, err = tx.Exec(
-			`INSERT INTO poems_likes (id, user_id, poem_id, created_on)
-			VALUES ($1, $2, $3, $4);`,
+			`INSERT INTO poems_likes (id, user_id, poem_id, reaction, created_on)
+			VALUES ($1, $2, $3, $4, $5);`,
 			newPoemLike.Id,
 			newPoemLike.UserId,
 			newPoemLike.PoemId,
+			newPoemLike.Reaction,
 			newPoemLike.CreatedOn,
 		)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
+		err = notifications.Add(tx, db_models.Notification{
+			UserId:    poem.UserId,
+			ActorId:   jsonBody.UserId,
+			Type:      db_models.NotificationTypeReaction,
+			PoemId:    jsonBody.PoemId,
+			GroupKey:  notifications.GetGroupKey(db_models.NotificationTypeReaction, jsonBody.PoemId),
+			CreatedOn: currentTime,
+		})
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
 		err = tx.Commit()
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -451,97 +681,156 @@ func ChangePoemReaction(c *gin.Context) {
 			200,
 			gin.H{
 				"success": true,
-				"data":    gin.H{"status": true},
+				"data":    gin.H{"status": true, "reaction": reaction},
 			},
 		)
 	}
 }
 
-// Retrieves poems created by the current user.
-func GetPoemsUserCreated(c *gin.Context) {
-	userId := c.Query("id")
+// Retrieves the reactions users can have on poems.
+func GetReactionTypes(c *gin.Context) {
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"reactions": utils.GetPoemReactions(),
+				"default":   utils.GetDefaultPoemReaction(),
+			},
+		},
+	)
+}
+
+// Retrieves the users who reacted to a poem and their reactions.
+// An optional reaction query parameter limits the list to one reaction.
+func GetPoemReactions(c *gin.Context) {
+	poemId := c.Query("id")
+	reaction := c.DefaultQuery("reaction", "")
 	pageSpec, err := utils.GetPageSpec(c, true)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	authToken, err := utils.DecodeAuthToken(c.Query("token"))
-	db, err := utils.GetDBConnection()
-	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+	if len(reaction) > 0 && !utils.IsValidPoemReaction(reaction) {
+		c.JSON(200, gin.H{"success": false, "message": "Invalid reaction."})
 		return
 	}
-	poems := []db_models.Poem{}
-	err = db.Select(&poems, "SELECT * FROM poems WHERE user_id=$1;", userId)
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	db, err := utils.GetDBConnection()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	sort.SliceStable(poems, func(i, j int) bool {
-		return poems[i].CreatedOn.After(poems[j].CreatedOn)
-	})
-	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
-	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
-	user := &db_models.User{}
-	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", userId)
+	poem := &db_models.Poem{}
+	err = db.Get(
+		poem,
+		"SELECT * FROM poems WHERE id=$1 AND status='published';",
+		poemId,
+	)
 	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": "Failed to find comment creator."})
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
 		return
 	}
-	for i := 0; i < len(pagePoems); i++ {
-		repliesCount := 0
+	poemLikes := []db_models.PoemLike{}
+	if len(reaction) > 0 {
 		err = db.Select(
-			&repliesCount,
-			"SELECT COUNT(*) AS replies_count FROM comments WHERE poem_id=$1 AND comment_id=$2",
-			pagePoems[i].Id,
-			"",
+			&poemLikes,
+			"SELECT * FROM poems_likes WHERE poem_id=$1 AND reaction=$2;",
+			poemId,
+			reaction,
 		)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": err.Error()})
-			return
-		}
-		likesCount := 0
+	} else {
 		err = db.Select(
-			&likesCount,
-			"SELECT COUNT(*) AS likes_count FROM poems_likes WHERE poem_id=$1;",
-			pagePoems[i].Id,
+			&poemLikes,
+			"SELECT * FROM poems_likes WHERE poem_id=$1;",
+			poemId,
 		)
+	}
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(poemLikes, func(i, j int) bool {
+		return poemLikes[i].CreatedOn.After(poemLikes[j].CreatedOn)
+	})
+	pagePoemLikes, err := utils.ExtractPage(poemLikes, *pageSpec)
+	pageReactionsObjs := make([]response_models.PoemReaction, len(pagePoemLikes))
+	for i, pagePoemLike := range pagePoemLikes {
+		user := &db_models.User{}
+		err = db.Get(user, "SELECT * FROM users WHERE id=$1;", pagePoemLike.UserId)
 		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
 			return
 		}
-		isLiked := false
+		isFollowingUser := false
 		if authToken != nil {
-			poemLike := &db_models.PoemLike{}
+			userFollowing := &db_models.UserFollowing{}
 			err = db.Get(
-				poemLike,
-				"SELECT * FROM poems_likes WHERE poem_id=$1 AND user_id=$2;",
-				pagePoems[i].Id,
+				userFollowing,
+				"SELECT * FROM users_followings WHERE follower_id=$1 AND following_id=$2;",
 				authToken.UserId,
+				user.Id,
 			)
-			isLiked = err == nil
+			isFollowingUser = err == nil
 		}
-		verses := []string{}
-		err = json.Unmarshal([]byte(pagePoems[i].Text), &verses)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": err.Error()})
-			return
-		}
-		pagePoemsObjs[i] = response_models.Poem{
-			Id: pagePoems[i].Id,
+		pageReactionsObjs[i] = response_models.PoemReaction{
 			User: response_models.UserMin{
 				Id:             user.Id,
 				Name:           user.Name,
+				Handle:         user.Handle,
 				ProfilePhotoId: user.ProfilePhotoId,
+				IsFollowing:    isFollowingUser,
 			},
-			Title:         pagePoems[i].Title,
-			PublishedOn:   pagePoems[i].CreatedOn.Format(time.RFC3339),
-			Verses:        verses,
-			CommentsCount: repliesCount,
-			LikesCount:    likesCount,
-			IsLiked:       isLiked,
+			Reaction:  pagePoemLike.Reaction,
+			CreatedOn: pagePoemLike.CreatedOn.UTC().Format(time.RFC3339),
 		}
 	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageReactionsObjs,
+		},
+	)
+}
+
+// Retrieves poems created by the current user.
+func GetPoemsUserCreated(c *gin.Context) {
+	userId := c.Query("id")
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	poems := []db_models.Poem{}
+	err = db.Select(
+		&poems,
+		"SELECT * FROM poems WHERE user_id=$1 AND status='published';",
+		userId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(poems, func(i, j int) bool {
+		return poems[i].PublishAt.After(poems[j].PublishAt)
+	})
+	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
+	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
+	for i := range pagePoems {
+		poemObj, err := getPoemObj(db, &pagePoems[i], authToken)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pagePoemsObjs[i] = *poemObj
+	}
 	c.JSON(
 		200,
 		gin.H{
@@ -582,69 +871,21 @@ func GetPoemsUserLikes(c *gin.Context) {
 	pagePoemsObjs := make([]response_models.Poem, len(pagePoemLikes))
 	for i := 0; i < len(pagePoemLikes); i++ {
 		poem := &db_models.Poem{}
-		err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", poem.Id)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
-			return
-		}
-		user := &db_models.User{}
-		err = db.Get(user, "SELECT * FROM users WHERE id=$1;", userId)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": "Failed to find poem creator."})
-			return
-		}
-		repliesCount := 0
-		err = db.Select(
-			&repliesCount,
-			"SELECT COUNT(*) AS replies_count FROM comments WHERE poem_id=$1 AND comment_id=$2",
-			poem.Id,
-			"",
-		)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": err.Error()})
-			return
-		}
-		likesCount := 0
-		err = db.Select(
-			&likesCount,
-			"SELECT COUNT(*) AS likes_count FROM poems_likes WHERE poem_id=$1;",
-			poem.Id,
+		err = db.Get(
+			poem,
+			"SELECT * FROM poems WHERE id=$1 AND status='published';",
+			pagePoemLikes[i].PoemId,
 		)
 		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
 			return
 		}
-		isLiked := false
-		if authToken != nil {
-			poemLike := &db_models.PoemLike{}
-			err = db.Get(
-				poemLike,
-				"SELECT * FROM poems_likes WHERE poem_id=$1 AND user_id=$2;",
-				poem.Id,
-				authToken.UserId,
-			)
-			isLiked = err == nil
-		}
-		verses := []string{}
-		err = json.Unmarshal([]byte(poem.Text), &verses)
+		poemObj, err := getPoemObj(db, poem, authToken)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		pagePoemsObjs[i] = response_models.Poem{
-			Id: poem.Id,
-			User: response_models.UserMin{
-				Id:             user.Id,
-				Name:           user.Name,
-				ProfilePhotoId: user.ProfilePhotoId,
-			},
-			Title:         poem.Title,
-			PublishedOn:   poem.CreatedOn.Format(time.RFC3339),
-			Verses:        verses,
-			CommentsCount: repliesCount,
-			LikesCount:    likesCount,
-			IsLiked:       isLiked,
-		}
+		pagePoemsObjs[i] = *poemObj
 	}
 	c.JSON(
 		200,
@@ -655,7 +896,7 @@ func GetPoemsUserLikes(c *gin.Context) {
 	)
 }
 
-// Retrieves poems for a user's timeline or home section.
+// Retrieves poems for a user's timeline or home section, newest first.
 func GetPoemsForChannel(c *gin.Context) {
 	pageSpec, err := utils.GetPageSpec(c, true)
 	if err != nil {
@@ -672,117 +913,73 @@ func GetPoemsForChannel(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	userFollowings := []db_models.UserFollowing{}
-	err = db.Select(
-		&userFollowings,
-		"SELECT * FROM users_followings WHERE follower_id=$1;",
-		authToken.UserId,
-	)
-	maxSize := math.MaxInt32
-	usersCount := len(userFollowings) + 1
-	poemsPerUser := int32(maxSize / usersCount)
-	users := make(map[string]response_models.UserMin, usersCount)
-	poems := []db_models.Poem{}
-	user := &db_models.User{}
-	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", authToken.UserId)
-	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+	if len(pageSpec.After) > 0 && len(pageSpec.Before) > 0 {
+		c.JSON(200, gin.H{"success": false, "message": "Only one page anchor needed."})
 		return
 	}
-	users[authToken.UserId] = response_models.UserMin{
-		Id:             user.Id,
-		Name:           user.Name,
-		ProfilePhotoId: user.ProfilePhotoId,
-		IsFollowing:    false,
+	anchorId := pageSpec.After
+	if len(pageSpec.Before) > 0 {
+		anchorId = pageSpec.Before
 	}
-	_ = db.Select(
-		&poems,
-		"SELECT * FROM poems WHERE user_id=$1 LIMIT $2;",
-		authToken.UserId,
-		poemsPerUser,
-	)
-	for i := range userFollowings {
-		userFollowingPoems := []db_models.Poem{}
-		user := &db_models.User{}
-		err = db.Get(
-			user,
-			"SELECT * FROM users WHERE id=$1;",
-			userFollowings[i].FollowingId,
-		)
+	anchor := &db_models.Poem{}
+	if len(anchorId) > 0 {
+		err = db.Get(anchor, "SELECT * FROM poems WHERE id=$1;", anchorId)
 		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
-			return
-		}
-		users[userFollowings[i].FollowingId] = response_models.UserMin{
-			Id:             user.Id,
-			Name:           user.Name,
-			ProfilePhotoId: user.ProfilePhotoId,
-			IsFollowing:    true,
-		}
-		err = db.Select(
-			&userFollowingPoems,
-			"SELECT * FROM poems WHERE user_id=$1 LIMIT $2;",
-			userFollowings[i].FollowingId,
-			poemsPerUser,
-		)
-		if err == nil {
-			poems = append(poems, userFollowingPoems...)
+			anchorId = ""
 		}
 	}
-	sort.SliceStable(poems, func(i, j int) bool {
-		return poems[i].CreatedOn.After(poems[j].CreatedOn)
-	})
-	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
-	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
-	for i, pagePoem := range pagePoems {
-		repliesCount := 0
-		err = db.Select(
-			&repliesCount,
-			"SELECT COUNT(*) AS replies_count FROM comments WHERE poem_id=$1 AND comment_id=$2",
-			pagePoem.Id,
-			"",
-		)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": err.Error()})
-			return
-		}
-		likesCount := 0
-		err = db.Select(
-			&likesCount,
-			"SELECT COUNT(*) AS likes_count FROM poems_likes WHERE poem_id=$1;",
-			pagePoems[i].Id,
-		)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": err.Error()})
-			return
+	// the feed merges the user's timeline with the poems of followed
+	// authors that are too popular to be copied to their followers' timelines
+	timelinesKeyset, poemsKeyset, order := "", "", "DESC"
+	args := []any{authToken.UserId, pageSpec.Span}
+	if len(anchorId) > 0 {
+		comparison := "<"
+		if len(pageSpec.Before) > 0 {
+			comparison, order = ">=", "ASC"
 		}
-		isLiked := false
-		if authToken != nil {
-			poemLike := &db_models.PoemLike{}
-			err = db.Get(
-				poemLike,
-				"SELECT * FROM poems_likes WHERE poem_id=$1 AND user_id=$2;",
-				pagePoems[i].Id,
-				authToken.UserId,
+		timelinesKeyset = "AND (timelines.publish_at, timelines.poem_id) " + comparison + " ($3, $4)"
+		poemsKeyset = "AND (poems.publish_at, poems.id) " + comparison + " ($3, $4)"
+		args = append(args, anchor.PublishAt, anchor.Id)
+	}
+	pagePoems := []db_models.Poem{}
+	err = db.Select(
+		&pagePoems,
+		fmt.Sprintf(
+			`SELECT poems.* FROM poems WHERE poems.status='published' AND poems.id IN (
+				(SELECT timelines.poem_id FROM timelines
+				WHERE timelines.user_id=$1 %[1]s
+				ORDER BY timelines.publish_at %[3]s, timelines.poem_id %[3]s LIMIT $2)
+				UNION ALL
+				(SELECT poems.id FROM poems
+				INNER JOIN users_followings ON users_followings.following_id=poems.user_id
+				WHERE users_followings.follower_id=$1 AND poems.status='published'
+					AND poems.is_fanned_out=FALSE %[2]s
+				ORDER BY poems.publish_at %[3]s, poems.id %[3]s LIMIT $2)
 			)
-			isLiked = err == nil
+			ORDER BY poems.publish_at %[3]s, poems.id %[3]s LIMIT $2;`,
+			timelinesKeyset,
+			poemsKeyset,
+			order,
+		),
+		args...,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if order == "ASC" {
+		for i, j := 0, len(pagePoems)-1; i < j; i, j = i+1, j-1 {
+			pagePoems[i], pagePoems[j] = pagePoems[j], pagePoems[i]
 		}
-		verses := []string{}
-		err = json.Unmarshal([]byte(pagePoems[i].Text), &verses)
+	}
+	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
+	for i := range pagePoems {
+		poemObj, err := getPoemObj(db, &pagePoems[i], authToken)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		pagePoemsObjs[i] = response_models.Poem{
-			Id:            pagePoems[i].Id,
-			User:          users[pagePoem.UserId],
-			Title:         pagePoems[i].Title,
-			PublishedOn:   pagePoems[i].CreatedOn.Format(time.RFC3339),
-			Verses:        verses,
-			CommentsCount: repliesCount,
-			LikesCount:    likesCount,
-			IsLiked:       isLiked,
-		}
+		pagePoemsObjs[i] = *poemObj
 	}
 	c.JSON(
 		200,
@@ -793,7 +990,9 @@ func GetPoemsForChannel(c *gin.Context) {
 	)
 }
 
-// Retrieves poems a user can explore.
+// Retrieves poems a user can explore, in the order of the explore rankings.
+// Poems by the user, by the users they follow and by the users they have
+// a block with are left out.
 func GetPoemsToExplore(c *gin.Context) {
 	pageSpec, err := utils.GetPageSpec(c, true)
 	if err != nil {
@@ -810,83 +1009,89 @@ func GetPoemsToExplore(c *gin.Context) {
 	if authToken != nil {
 		userId = authToken.UserId
 	}
-	poems := []db_models.Poem{}
-	err = db.Select(
-		&poems,
-		`SELECT * FROM poems WHERE user_id NOT IN
-			(SELECT following_id FROM users_followings WHERE follower_id=$1;)
-			LIMIT $2;`,
-		userId,
-		MAX_EXPLORE_PAGE_POEMS,
-	)
-	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+	if len(pageSpec.After) > 0 && len(pageSpec.Before) > 0 {
+		c.JSON(200, gin.H{"success": false, "message": "Only one page anchor needed."})
 		return
 	}
-	sort.SliceStable(poems, func(i, j int) bool {
-		return poems[i].CreatedOn.After(poems[j].CreatedOn)
-	})
-	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
-	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
-	for i, pagePoem := range pagePoems {
-		user := &db_models.User{}
-		err = db.Get(user, "SELECT * FROM users WHERE id=$1;", pagePoem.UserId)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": "Failed to find poem creator."})
-			return
-		}
-		repliesCount := 0
-		err = db.Select(
-			&repliesCount,
-			"SELECT COUNT(*) AS replies_count FROM comments WHERE poem_id=$1 AND comment_id=$2",
-			pagePoem.Id,
-			"",
+	if pageSpec.Span > MAX_EXPLORE_PAGE_POEMS {
+		pageSpec.Span = MAX_EXPLORE_PAGE_POEMS
+	}
+	// pages are anchored on the rankings' order, so they stay
+	// stable until the rankings are refreshed
+	anchorId := pageSpec.After
+	if len(pageSpec.Before) > 0 {
+		anchorId = pageSpec.Before
+	}
+	anchorScore := 0.0
+	if len(anchorId) > 0 {
+		err = db.Get(
+			&anchorScore,
+			"SELECT score FROM explore_rankings WHERE poem_id=$1;",
+			anchorId,
 		)
-		if err != nil {
+		if err == sql.ErrNoRows {
+			// silently restarting from the first page would repeat seen poems
+			c.JSON(200, gin.H{
+				"success": false,
+				"message": "Explore feed has been refreshed, reload it from its first page.",
+			})
+			return
+		} else if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		likesCount := 0
+	}
+	feedQuery := `SELECT poems.* FROM explore_rankings
+		INNER JOIN poems ON poems.id=explore_rankings.poem_id
+		WHERE poems.status='published' AND poems.user_id<>$1 AND poems.user_id NOT IN (
+			SELECT following_id FROM users_followings WHERE follower_id=$1
+		) AND ` + getUnblockedUserCondition("poems.user_id", "$1")
+	pagePoems := []db_models.Poem{}
+	if len(anchorId) > 0 && len(pageSpec.After) > 0 {
 		err = db.Select(
-			&likesCount,
-			"SELECT COUNT(*) AS likes_count FROM poems_likes WHERE poem_id=$1;",
-			pagePoem.Id,
+			&pagePoems,
+			feedQuery+` AND (explore_rankings.score<$2
+				OR (explore_rankings.score=$2 AND explore_rankings.poem_id>$3))
+			ORDER BY explore_rankings.score DESC, explore_rankings.poem_id LIMIT $4;`,
+			userId,
+			anchorScore,
+			anchorId,
+			pageSpec.Span,
 		)
-		if err != nil {
-			c.JSON(200, gin.H{"success": false, "message": err.Error()})
-			return
-		}
-		isLiked := false
-		if authToken != nil {
-			poemLike := &db_models.PoemLike{}
-			err = db.Get(
-				poemLike,
-				"SELECT * FROM poems_likes WHERE poem_id=$1 AND user_id=$2;",
-				pagePoem.Id,
-				authToken.UserId,
-			)
-			isLiked = err == nil
+	} else if len(anchorId) > 0 {
+		err = db.Select(
+			&pagePoems,
+			feedQuery+` AND (explore_rankings.score>$2
+				OR (explore_rankings.score=$2 AND explore_rankings.poem_id<=$3))
+			ORDER BY explore_rankings.score, explore_rankings.poem_id DESC LIMIT $4;`,
+			userId,
+			anchorScore,
+			anchorId,
+			pageSpec.Span,
+		)
+		for i, j := 0, len(pagePoems)-1; i < j; i, j = i+1, j-1 {
+			pagePoems[i], pagePoems[j] = pagePoems[j], pagePoems[i]
 		}
-		verses := []string{}
-		err = json.Unmarshal([]byte(pagePoem.Text), &verses)
+	} else {
+		err = db.Select(
+			&pagePoems,
+			feedQuery+` ORDER BY explore_rankings.score DESC, explore_rankings.poem_id LIMIT $2;`,
+			userId,
+			pageSpec.Span,
+		)
+	}
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
+	for i := range pagePoems {
+		poemObj, err := getPoemObj(db, &pagePoems[i], authToken)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		pagePoemsObjs[i] = response_models.Poem{
-			Id: pagePoem.Id,
-			User: response_models.UserMin{
-				Id:             user.Id,
-				Name:           user.Name,
-				ProfilePhotoId: user.ProfilePhotoId,
-			},
-			Title:         pagePoem.Title,
-			PublishedOn:   pagePoem.CreatedOn.Format(time.RFC3339),
-			Verses:        verses,
-			CommentsCount: repliesCount,
-			LikesCount:    likesCount,
-			IsLiked:       isLiked,
-		}
+		pagePoemsObjs[i] = *poemObj
 	}
 	c.JSON(
 		200,
@@ -896,3 +1101,121 @@ func GetPoemsToExplore(c *gin.Context) {
 		},
 	)
 }
+
+// Creates the response object of a poem as seen by a viewer.
+func getPoemObj(
+	db *sqlx.DB,
+	poem *db_models.Poem,
+	authToken *utils.AuthToken,
+) (*response_models.Poem, error) {
+	user := &db_models.User{}
+	err := db.Get(user, "SELECT * FROM users WHERE id=$1;", poem.UserId)
+	if err != nil {
+		return nil, errors.New("Failed to find poem creator.")
+	}
+	commentsCount := 0
+	err = db.Get(
+		&commentsCount,
+		"SELECT COUNT(*) AS comments_count FROM comments WHERE poem_id=$1 AND comment_id=$2;",
+		poem.Id,
+		"",
+	)
+	if err != nil {
+		return nil, err
+	}
+	reactionsCounts := []struct {
+		Reaction string `db:"reaction"`
+		Count    int    `db:"reactions_count"`
+	}{}
+	err = db.Select(
+		&reactionsCounts,
+		`SELECT reaction, COUNT(*) AS reactions_count FROM poems_likes
+		WHERE poem_id=$1 GROUP BY reaction;`,
+		poem.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	likesCount := 0
+	reactions := make(map[string]int)
+	for _, reaction := range utils.GetPoemReactions() {
+		reactions[reaction] = 0
+	}
+	for _, reactionsCount := range reactionsCounts {
+		likesCount += reactionsCount.Count
+		reactions[reactionsCount.Reaction] = reactionsCount.Count
+	}
+	tags := []string{}
+	err = db.Select(
+		&tags,
+		`SELECT tags.name FROM tags
+		INNER JOIN poems_tags ON poems_tags.tag_id=tags.id
+		WHERE poems_tags.poem_id=$1
+		ORDER BY poems_tags.created_on, tags.name;`,
+		poem.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	isLiked, isBookmarked, isFollowingUser := false, false, false
+	viewerReaction := ""
+	if authToken != nil {
+		poemLike := &db_models.PoemLike{}
+		err = db.Get(
+			poemLike,
+			"SELECT * FROM poems_likes WHERE poem_id=$1 AND user_id=$2;",
+			poem.Id,
+			authToken.UserId,
+		)
+		isLiked = err == nil
+		viewerReaction = poemLike.Reaction
+		bookmark := &db_models.Bookmark{}
+		err = db.Get(
+			bookmark,
+			"SELECT * FROM bookmarks WHERE poem_id=$1 AND user_id=$2;",
+			poem.Id,
+			authToken.UserId,
+		)
+		isBookmarked = err == nil
+		userFollowing := &db_models.UserFollowing{}
+		err = db.Get(
+			userFollowing,
+			"SELECT * FROM users_followings WHERE follower_id=$1 AND following_id=$2;",
+			authToken.UserId,
+			poem.UserId,
+		)
+		isFollowingUser = err == nil
+	}
+	body, err := utils.ParsePoemBody(poem.Text)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := getPoemMentions(db, poem.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &response_models.Poem{
+		Id: poem.Id,
+		User: response_models.UserMin{
+			Id:             user.Id,
+			Name:           user.Name,
+			Handle:         user.Handle,
+			ProfilePhotoId: user.ProfilePhotoId,
+			IsFollowing:    isFollowingUser,
+		},
+		Title:         poem.Title,
+		PublishedOn:   poem.PublishAt.Format(time.RFC3339),
+		Verses:        body.Verses(),
+		Body:          *body,
+		Tags:          tags,
+		Status:        poem.Status,
+		Language:      poem.Language,
+		CommentsCount: commentsCount,
+		LikesCount:    likesCount,
+		Reactions:     reactions,
+		IsLiked:       isLiked,
+		Reaction:      viewerReaction,
+		IsBookmarked:  isBookmarked,
+		Mentions:      mentions,
+	}, nil
+}
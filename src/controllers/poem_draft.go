@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/notifications"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/timelines"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Resolves the status and publishing time of a poem from a request.
+func getPoemSchedule(status, publishAt string, currentTime time.Time) (string, time.Time, error) {
+	switch status {
+	case "", db_models.PoemStatusPublished:
+		return db_models.PoemStatusPublished, currentTime, nil
+	case db_models.PoemStatusDraft:
+		return db_models.PoemStatusDraft, currentTime, nil
+	case db_models.PoemStatusScheduled:
+		publishTime, err := time.Parse(time.RFC3339, publishAt)
+		if err != nil {
+			return "", currentTime, errors.New("Invalid publishing time.")
+		}
+		if !publishTime.After(currentTime) {
+			return "", currentTime, errors.New("Publishing time must be in the future.")
+		}
+		return db_models.PoemStatusScheduled, publishTime.UTC(), nil
+	}
+	return "", currentTime, errors.New("Invalid poem status.")
+}
+
+// Retrieves the current user's draft and scheduled poems.
+func GetPoemDrafts(c *gin.Context) {
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", authToken.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+		return
+	}
+	poems := []db_models.Poem{}
+	err = db.Select(
+		&poems,
+		"SELECT * FROM poems WHERE user_id=$1 AND status<>'published';",
+		authToken.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(poems, func(i, j int) bool {
+		return poems[i].UpdatedOn.After(poems[j].UpdatedOn)
+	})
+	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
+	pagePoemsObjs := make([]gin.H, len(pagePoems))
+	for i, pagePoem := range pagePoems {
+		body, err := utils.ParsePoemBody(pagePoem.Text)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pagePoemsObjs[i] = gin.H{
+			"id": pagePoem.Id,
+			"user": response_models.UserMin{
+				Id:             user.Id,
+				Name:           user.Name,
+				Handle:         user.Handle,
+				ProfilePhotoId: user.ProfilePhotoId,
+			},
+			"title":     pagePoem.Title,
+			"verses":    body.Verses(),
+			"body":      body,
+			"status":    pagePoem.Status,
+			"language":  pagePoem.Language,
+			"publishAt": pagePoem.PublishAt.UTC().Format(time.RFC3339),
+			"createdOn": pagePoem.CreatedOn.UTC().Format(time.RFC3339),
+			"updatedOn": pagePoem.UpdatedOn.UTC().Format(time.RFC3339),
+		}
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pagePoemsObjs,
+		},
+	)
+}
+
+// Edits, schedules or publishes a poem that hasn't been published yet.
+func UpdatePoemDraft(c *gin.Context) {
+	var jsonBody request_models.PoemDraftUpdateForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	currentTime := time.Now().UTC()
+	poem := &db_models.Poem{}
+	err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", jsonBody.PoemId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	if poem.UserId != jsonBody.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "You are not allowed to edit this poem."})
+		return
+	}
+	if poem.Status == db_models.PoemStatusPublished {
+		c.JSON(200, gin.H{"success": false, "message": "Poem has already been published."})
+		return
+	}
+	status, publishAt, err := getPoemSchedule(jsonBody.Status, jsonBody.PublishAt, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	language := poem.Language
+	if len(jsonBody.Language) > 0 {
+		language, err = utils.GetSearchLanguage(jsonBody.Language)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
+	if len(jsonBody.Title) > 256 {
+		c.JSON(200, gin.H{"success": false, "message": "Title is too long."})
+		return
+	}
+	body, err := getPoemFormBody(jsonBody.Verses, jsonBody.Body)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	bodyTxt, err := body.Encode()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	tags, err := utils.GetPoemTags(jsonBody.Tags, body.Verses())
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`UPDATE poems SET
+			updated_on=$1, title=$2, text=$3, status=$4, publish_at=$5, language=$6
+			WHERE id=$7;`,
+		currentTime,
+		jsonBody.Title,
+		bodyTxt,
+		status,
+		publishAt,
+		language,
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = setPoemTags(tx, jsonBody.PoemId, tags, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = setPoemMentions(tx, poem.Id, poem.UserId, bodyTxt, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if status == db_models.PoemStatusPublished {
+		err = notifications.AddMentions(tx, poem.UserId, poem.Id, "", currentTime)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = timelines.AddPoem(tx, poem.Id, poem.UserId)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = events.Publish(tx, events.Event{
+			Type:     events.EventPoemCreated,
+			AuthorId: poem.UserId,
+			PoemId:   poem.Id,
+		})
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	poem.Title = jsonBody.Title
+	poem.Text = bodyTxt
+	poem.Status = status
+	poem.PublishAt = publishAt
+	poem.Language = language
+	search.SyncPoem(poem)
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"status":    status,
+				"publishAt": publishAt.Format(time.RFC3339),
+				"tags":      tags,
+			},
+		},
+	)
+}
@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/notifications"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Retrieves the previous versions of a poem.
+func GetPoemRevisions(c *gin.Context) {
+	poemId := c.Query("id")
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	poem := &db_models.Poem{}
+	err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", poemId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	if poem.Status != db_models.PoemStatusPublished &&
+		(authToken == nil || authToken.UserId != poem.UserId) {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	revisions := []db_models.PoemRevision{}
+	err = db.Select(
+		&revisions,
+		"SELECT * FROM poem_revisions WHERE poem_id=$1;",
+		poemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].CreatedOn.After(revisions[j].CreatedOn)
+	})
+	pageRevisions, err := utils.ExtractPage(revisions, *pageSpec)
+	pageRevisionsObjs := make([]response_models.PoemRevision, len(pageRevisions))
+	for i, pageRevision := range pageRevisions {
+		body, err := utils.ParsePoemBody(pageRevision.Text)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pageRevisionsObjs[i] = response_models.PoemRevision{
+			Id:        pageRevision.Id,
+			PoemId:    pageRevision.PoemId,
+			Title:     pageRevision.Title,
+			Verses:    body.Verses(),
+			Body:      *body,
+			CreatedOn: pageRevision.CreatedOn.Format(time.RFC3339),
+		}
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageRevisionsObjs,
+		},
+	)
+}
+
+// Retrieves the verse-level differences between two versions of a poem.
+// An empty "to" revision id refers to the poem's current version.
+func GetPoemRevisionsDiff(c *gin.Context) {
+	poemId := c.Query("id")
+	fromId := c.Query("from")
+	toId := c.DefaultQuery("to", "")
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	poem := &db_models.Poem{}
+	err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", poemId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	if poem.Status != db_models.PoemStatusPublished &&
+		(authToken == nil || authToken.UserId != poem.UserId) {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	fromRevision := &db_models.PoemRevision{}
+	err = db.Get(
+		fromRevision,
+		"SELECT * FROM poem_revisions WHERE id=$1 AND poem_id=$2;",
+		fromId,
+		poemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find revision."})
+		return
+	}
+	toRevision := &db_models.PoemRevision{
+		PoemId:    poem.Id,
+		Title:     poem.Title,
+		Text:      poem.Text,
+		CreatedOn: poem.UpdatedOn,
+	}
+	if len(toId) > 0 {
+		err = db.Get(
+			toRevision,
+			"SELECT * FROM poem_revisions WHERE id=$1 AND poem_id=$2;",
+			toId,
+			poemId,
+		)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": "Failed to find revision."})
+			return
+		}
+	}
+	fromVerses, err := utils.GetPoemVerses(fromRevision.Text)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	toVerses, err := utils.GetPoemVerses(toRevision.Text)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"poemId": poem.Id,
+				"from": gin.H{
+					"id":        fromRevision.Id,
+					"title":     fromRevision.Title,
+					"createdOn": fromRevision.CreatedOn.UTC().Format(time.RFC3339),
+				},
+				"to": gin.H{
+					"id":        toRevision.Id,
+					"title":     toRevision.Title,
+					"createdOn": toRevision.CreatedOn.UTC().Format(time.RFC3339),
+				},
+				"verses": utils.DiffVerses(fromVerses, toVerses),
+			},
+		},
+	)
+}
+
+// Restores a poem to one of its previous versions.
+func RestorePoemRevision(c *gin.Context) {
+	var jsonBody request_models.PoemRevisionRestoreForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	currentTime := time.Now().UTC()
+	poem := &db_models.Poem{}
+	err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", jsonBody.PoemId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	if poem.UserId != jsonBody.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "You are not allowed to edit this poem."})
+		return
+	}
+	revision := &db_models.PoemRevision{}
+	err = db.Get(
+		revision,
+		"SELECT * FROM poem_revisions WHERE id=$1 AND poem_id=$2;",
+		jsonBody.RevisionId,
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find revision."})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// keep the poem's current version as a revision
+	_, err = tx.Exec(
+		`INSERT INTO poem_revisions (id, poem_id, user_id, title, text, created_on)
+		VALUES ($1, $2, $3, $4, $5, $6);`,
+		uuid.New().String(),
+		poem.Id,
+		poem.UserId,
+		poem.Title,
+		poem.Text,
+		poem.UpdatedOn,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`UPDATE poems SET
+			updated_on=$1, title=$2, text=$3
+			WHERE id=$4;`,
+		currentTime,
+		revision.Title,
+		revision.Text,
+		jsonBody.PoemId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = remapPoemAnnotations(db, tx, poem.Id, poem.Text, revision.Text)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = setPoemMentions(tx, poem.Id, poem.UserId, revision.Text, currentTime)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if poem.Status == db_models.PoemStatusPublished {
+		err = notifications.AddMentions(tx, poem.UserId, poem.Id, "", currentTime)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		err = events.Publish(tx, events.Event{
+			Type:     events.EventPoemUpdated,
+			AuthorId: poem.UserId,
+			PoemId:   poem.Id,
+		})
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	poem.Title = revision.Title
+	poem.Text = revision.Text
+	search.SyncPoem(poem)
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"updatedOn": currentTime.Format(time.RFC3339),
+			},
+		},
+	)
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// The maximum number of poems similar to a poem.
+	MAX_SIMILAR_POEMS = 24
+	// The number of a poem's most frequent words its similar poems are matched with.
+	SIMILAR_POEM_WORDS = 24
+	// The weight of the text similarity between two poems.
+	SIMILAR_TEXT_WEIGHT = 1.0
+	// The weight of the share of users who liked both poems.
+	SIMILAR_LIKES_WEIGHT = 0.5
+	// The words of a poem's title and verses that poems are compared by.
+	SIMILAR_POEM_VECTOR = `(to_tsvector('english', poems.title) ||
+		jsonb_to_tsvector('english', poems.text::jsonb, '["string"]'))`
+)
+
+// Retrieves published poems similar to a given poem, from the words they
+// share with it and the users who liked both of them. Poems by users
+// who have a block with the viewer are left out.
+func GetSimilarPoems(c *gin.Context) {
+	poemId := c.Query("id")
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	poem := &db_models.Poem{}
+	err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", poemId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	if poem.Status != db_models.PoemStatusPublished &&
+		(authToken == nil || authToken.UserId != poem.UserId) {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find poem."})
+		return
+	}
+	viewerId := ""
+	if authToken != nil {
+		viewerId = authToken.UserId
+	}
+	// the poem's most frequent words are matched against other poems and
+	// the users who liked it are matched against the users who liked others
+	poems := []db_models.Poem{}
+	err = db.Select(
+		&poems,
+		`WITH source AS (
+			SELECT (
+				SELECT string_agg(quote_literal(lexeme), ' | ') FROM (
+					SELECT lexeme FROM unnest(`+SIMILAR_POEM_VECTOR+`)
+					WHERE position('\' in lexeme)=0
+					ORDER BY array_length(positions, 1) DESC, lexeme LIMIT $2
+				) AS words
+			)::tsquery AS query
+			FROM poems WHERE id=$1
+		), co_likes AS (
+			SELECT others_likes.poem_id,
+				COUNT(*) / sqrt(
+					(SELECT COUNT(*) FROM poems_likes WHERE poem_id=$1) *
+					(SELECT COUNT(*) FROM poems_likes WHERE poem_id=others_likes.poem_id)
+				) AS similarity
+			FROM poems_likes
+			INNER JOIN poems_likes AS others_likes ON others_likes.user_id=poems_likes.user_id
+			WHERE poems_likes.poem_id=$1 AND others_likes.poem_id<>$1
+			GROUP BY others_likes.poem_id
+		)
+		SELECT poems.* FROM poems
+		CROSS JOIN source
+		LEFT JOIN co_likes ON co_likes.poem_id=poems.id
+		WHERE poems.id<>$1 AND poems.status='published' AND `+getUnblockedUserCondition("poems.user_id", "$6")+` AND (
+			co_likes.poem_id IS NOT NULL OR
+			`+SIMILAR_POEM_VECTOR+` @@ source.query
+		)
+		ORDER BY (
+			$3 * COALESCE(ts_rank(`+SIMILAR_POEM_VECTOR+`, source.query), 0) +
+			$4 * COALESCE(co_likes.similarity, 0)
+		) DESC, poems.id
+		LIMIT $5;`,
+		poem.Id,
+		SIMILAR_POEM_WORDS,
+		SIMILAR_TEXT_WEIGHT,
+		SIMILAR_LIKES_WEIGHT,
+		MAX_SIMILAR_POEMS,
+		viewerId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
+	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
+	for i := range pagePoems {
+		poemObj, err := getPoemObj(db, &pagePoems[i], authToken)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pagePoemsObjs[i] = *poemObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pagePoemsObjs,
+		},
+	)
+}
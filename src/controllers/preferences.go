@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Retrieves the current user's notification preferences.
+func GetUserPreferences(c *gin.Context) {
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", authToken.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"digestFrequency": user.DigestFrequency,
+			},
+		},
+	)
+}
+
+// Updates the current user's notification preferences.
+func UpdateUserPreferences(c *gin.Context) {
+	var jsonBody request_models.UserPreferencesForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	switch jsonBody.DigestFrequency {
+	case db_models.DigestFrequencyNone,
+		db_models.DigestFrequencyDaily,
+		db_models.DigestFrequencyWeekly:
+	default:
+		c.JSON(200, gin.H{"success": false, "message": "Invalid digest frequency."})
+		return
+	}
+	currentTime := time.Now().UTC()
+	user := &db_models.User{}
+	err = db.Get(user, "SELECT * FROM users WHERE id=$1;", jsonBody.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
+		return
+	}
+	// the first digest after subscribing only covers what happens afterwards
+	lastDigestOn := user.LastDigestOn
+	if user.DigestFrequency == db_models.DigestFrequencyNone {
+		lastDigestOn = currentTime
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`UPDATE users SET
+			updated_on=$1, digest_frequency=$2, last_digest_on=$3
+			WHERE id=$4;`,
+		currentTime,
+		jsonBody.DigestFrequency,
+		lastDigestOn,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"digestFrequency": jsonBody.DigestFrequency,
+			},
+		},
+	)
+}
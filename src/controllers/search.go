@@ -1,25 +1,648 @@
 package controllers
 
 import (
-	"encoding/json"
+	"errors"
+	"html"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-// Retrieves a list of poems that match a given query.
-func FindPoems(c *gin.Context) {
+const (
+	// The maximum number of results of a search.
+	MAX_SEARCH_RESULTS = 255
+	// The maximum number of matches retrieved from a search index other than
+	// the database's before a search's filters are applied.
+	MAX_SEARCH_INDEX_HITS = 1000
+	// The minimum length of a query for poems.
+	MIN_POEMS_QUERY_LENGTH = 3
+	// The minimum length of a query for people.
+	MIN_PEOPLE_QUERY_LENGTH = 2
+	// The maximum number of people and poems suggested as a query is typed.
+	MAX_AUTOCOMPLETE_SIZE = 20
+	// The maximum number of values of a facet of a search's results.
+	MAX_SEARCH_FACET_VALUES = 10
+)
+
+const (
+	SEARCH_TYPE_POEMS    = "poems"
+	SEARCH_TYPE_PEOPLE   = "people"
+	SEARCH_TYPE_TAGS     = "tags"
+	SEARCH_TYPE_COMMENTS = "comments"
+)
+
+const (
+	SEARCH_SORT_RELEVANCE = "relevance"
+	SEARCH_SORT_NEWEST    = "newest"
+	SEARCH_SORT_OLDEST    = "oldest"
+	SEARCH_SORT_LIKES     = "likes"
+)
+
+// Represents the highlighted matches of a poem in a search.
+type poemSearchMatch struct {
+	TitleHighlight  string `db:"title_highlight"`
+	VersesHighlight string `db:"verses_highlight"`
+}
+
+// Represents the highlighted matches of a user in a search.
+type userSearchMatch struct {
+	NameHighlight string `db:"name_highlight"`
+	BioHighlight  string `db:"bio_highlight"`
+}
+
+// Retrieves a search query with a minimum length from a gin Context.
+func getSearchQuery(c *gin.Context, minLength int) (string, bool) {
 	query := strings.ReplaceAll(strings.Trim(c.Query("q"), " "), "'", "")
-	if len(query) < 3 {
+	if len(query) < minLength {
 		c.JSON(200, gin.H{"success": false, "message": "Query is too short."})
-		return
+		return "", false
 	}
 	if strings.Count(query, "\"")%2 != 0 {
 		c.JSON(200, gin.H{"success": false, "message": "Unequal number of quotes."})
+		return "", false
+	}
+	return query, true
+}
+
+// Creates the search result of a poem, with its rank and the
+// snippets of its title and verses highlighting the query's matches.
+// The query is read in the poem's language, as its text is indexed.
+func getPoemSearchResult(
+	db *sqlx.DB,
+	poem *db_models.Poem,
+	query string,
+	rank float64,
+	authToken *utils.AuthToken,
+) (*response_models.PoemSearchResult, error) {
+	poemObj, err := getPoemObj(db, poem, authToken)
+	if err != nil {
+		return nil, err
+	}
+	// the text is escaped so that the highlights' only markup is their own
+	match := &poemSearchMatch{}
+	err = db.Get(
+		match,
+		`SELECT ts_headline(poems.language, $2::text, websearch_to_tsquery(poems.language, $1), $4)
+				AS title_highlight,
+			ts_headline(poems.language, $3::text, websearch_to_tsquery(poems.language, $1), $4)
+				AS verses_highlight
+		FROM poems WHERE id=$5;`,
+		query,
+		html.EscapeString(poem.Title),
+		html.EscapeString(strings.Join(poemObj.Verses, "\n")),
+		utils.SearchHeadlineOptions,
+		poem.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &response_models.PoemSearchResult{
+		Poem:            *poemObj,
+		TitleHighlight:  match.TitleHighlight,
+		VersesHighlight: match.VersesHighlight,
+		Rank:            rank,
+	}, nil
+}
+
+// Represents the filters and order of a search.
+type searchFilters struct {
+	Query    string
+	Language string
+	AuthorId string
+	From     time.Time
+	To       time.Time
+	MinLikes int
+	Sort     string
+	// The user searching, who doesn't find the users they have a block with.
+	ViewerId string
+}
+
+// Represents the arguments of a query built from search filters.
+type searchArgs []any
+
+// Adds an argument to a query and returns its placeholder.
+func (args *searchArgs) add(value any) string {
+	*args = append(*args, value)
+	return "$" + strconv.Itoa(len(*args))
+}
+
+// Represents the rows of a table matching a search's filters. A search's
+// results are the first of them, while its counts and facets cover all of them.
+type searchScope struct {
+	Table      string
+	Conditions []string
+	Args       searchArgs
+	// Whether the rows are all the matches of the search's query rather
+	// than the most relevant of them, which only bound its count below.
+	IsComplete bool
+}
+
+// Creates the condition of the rows matching a search.
+func (scope *searchScope) where() string {
+	return strings.Join(scope.Conditions, " AND ")
+}
+
+// Copies the arguments of the search's condition so that
+// a query can add its own arguments to them.
+func (scope *searchScope) args() *searchArgs {
+	args := append(searchArgs{}, scope.Args...)
+	return &args
+}
+
+// Counts the rows matching a search.
+func (scope *searchScope) count(db *sqlx.DB) (int, error) {
+	count := 0
+	err := db.Get(
+		&count,
+		"SELECT COUNT(*) FROM "+scope.Table+" WHERE "+scope.where()+";",
+		scope.Args...,
+	)
+	return count, err
+}
+
+// Parses the date or time bounding a search. A date given as the end
+// of a search's period includes the whole day.
+func parseSearchDate(value string, isEnd bool) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err == nil {
+		if isEnd {
+			date = date.Add(24 * time.Hour)
+		}
+		return date, nil
+	}
+	date, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid date.")
+	}
+	return date.UTC(), nil
+}
+
+// Retrieves the language of the poems a search is limited to from a gin
+// Context, which is empty when poems in every language are searched.
+func getSearchLanguageFilter(c *gin.Context) (string, error) {
+	if len(c.Query("lang")) == 0 {
+		return "", nil
+	}
+	return utils.GetSearchLanguage(c.Query("lang"))
+}
+
+// Retrieves the filters and order of a search from a gin Context.
+// The author can be given by their id or handle.
+func getSearchFilters(c *gin.Context, db *sqlx.DB, query string) (*searchFilters, error) {
+	language, err := getSearchLanguageFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	filters := &searchFilters{
+		Query:    query,
+		Language: language,
+		Sort:     c.DefaultQuery("sort", SEARCH_SORT_RELEVANCE),
+	}
+	switch filters.Sort {
+	case SEARCH_SORT_RELEVANCE, SEARCH_SORT_NEWEST, SEARCH_SORT_OLDEST, SEARCH_SORT_LIKES:
+	default:
+		return nil, errors.New("Invalid sort order.")
+	}
+	if author := c.Query("author"); len(author) > 0 {
+		err = db.Get(
+			&filters.AuthorId,
+			"SELECT id FROM users WHERE id=$1 OR lower(handle)=lower($1);",
+			strings.TrimPrefix(author, "@"),
+		)
+		if err != nil {
+			return nil, errors.New("Failed to find author.")
+		}
+	}
+	filters.From, err = parseSearchDate(c.Query("from"), false)
+	if err != nil {
+		return nil, err
+	}
+	filters.To, err = parseSearchDate(c.Query("to"), true)
+	if err != nil {
+		return nil, err
+	}
+	if minLikes := c.Query("minLikes"); len(minLikes) > 0 {
+		filters.MinLikes, err = strconv.Atoi(minLikes)
+		if err != nil || filters.MinLikes < 0 {
+			return nil, errors.New("Invalid minimum likes.")
+		}
+	}
+	return filters, nil
+}
+
+// Retrieves the ids of the documents of a kind matching a search from
+// the search index, with the most relevant first, and their relevance.
+func querySearchIndex(kind string, filters *searchFilters) ([]string, map[string]float64, error) {
+	index, err := search.GetIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	hits, err := index.Query(search.Query{
+		Kind:     kind,
+		Text:     filters.Query,
+		Language: filters.Language,
+		Limit:    MAX_SEARCH_INDEX_HITS,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]string, len(hits))
+	ranks := make(map[string]float64)
+	for i, hit := range hits {
+		ids[i] = hit.Id
+		ranks[hit.Id] = hit.Score
+	}
+	return ids, ranks, nil
+}
+
+// Creates the scope of the rows of a kind of document matching a search's
+// query, with the expression of their relevance, the highest being the most
+// relevant. The Postgres backend matches rows within the database so that
+// a search's filters apply to all its matches, while other backends' matches
+// are limited to their most relevant ones, whose relevance is returned too.
+func getSearchMatchScope(kind, table string, filters *searchFilters) (*searchScope, string, map[string]float64, error) {
+	index, err := search.GetIndex()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if _, ok := index.(search.PostgresIndex); ok {
+		scope := &searchScope{
+			Table:      table,
+			Args:       searchArgs{filters.Query, utils.EscapeLikePattern(strings.ToLower(filters.Query)) + "%"},
+			IsComplete: true,
+		}
+		if kind == search.DOCUMENT_POEM {
+			scope.Conditions = []string{search.GetPoemSearchCondition("$1", "$2")}
+			return scope, search.GetPoemSearchRank("$1"), nil, nil
+		}
+		scope.Conditions = []string{search.GetUserSearchCondition("$1", "$2")}
+		return scope, search.GetUserSearchRank("$1"), nil, nil
+	}
+	ids, ranks, err := querySearchIndex(kind, filters)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	scope := &searchScope{
+		Table:      table,
+		Conditions: []string{table + ".id=ANY($1)"},
+		Args:       searchArgs{pq.Array(ids)},
+		IsComplete: len(ids) < MAX_SEARCH_INDEX_HITS,
+	}
+	return scope, "-array_position($1::text[], " + table + ".id::text)", ranks, nil
+}
+
+// Finds the published poems matching a search and applies the search's
+// filters and order to them. The relevance of the poems and the scope
+// of the search are returned with them.
+func searchPoems(db *sqlx.DB, filters *searchFilters) ([]db_models.Poem, map[string]float64, *searchScope, error) {
+	scope, relevance, ranks, err := getSearchMatchScope(search.DOCUMENT_POEM, "poems", filters)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	scope.Conditions = append(scope.Conditions, "poems.status='published'")
+	if len(filters.Language) > 0 {
+		scope.Conditions = append(scope.Conditions, "poems.language="+scope.Args.add(filters.Language)+"::regconfig")
+	}
+	if len(filters.AuthorId) > 0 {
+		scope.Conditions = append(scope.Conditions, "poems.user_id="+scope.Args.add(filters.AuthorId))
+	}
+	if len(filters.ViewerId) > 0 {
+		scope.Conditions = append(
+			scope.Conditions,
+			getUnblockedUserCondition("poems.user_id", scope.Args.add(filters.ViewerId)),
+		)
+	}
+	if !filters.From.IsZero() {
+		scope.Conditions = append(scope.Conditions, "poems.publish_at>="+scope.Args.add(filters.From))
+	}
+	if !filters.To.IsZero() {
+		scope.Conditions = append(scope.Conditions, "poems.publish_at<"+scope.Args.add(filters.To))
+	}
+	likesCount := "(SELECT COUNT(*) FROM poems_likes WHERE poems_likes.poem_id=poems.id)"
+	if filters.MinLikes > 0 {
+		scope.Conditions = append(scope.Conditions, likesCount+">="+scope.Args.add(filters.MinLikes))
+	}
+	order := relevance + " DESC"
+	switch filters.Sort {
+	case SEARCH_SORT_NEWEST:
+		order = "poems.publish_at DESC"
+	case SEARCH_SORT_OLDEST:
+		order = "poems.publish_at ASC"
+	case SEARCH_SORT_LIKES:
+		order = likesCount + " DESC, " + relevance + " DESC"
+	}
+	args := scope.args()
+	rankedPoems := []struct {
+		db_models.Poem
+		Relevance float64 `db:"relevance"`
+	}{}
+	err = db.Select(
+		&rankedPoems,
+		`SELECT *, `+relevance+` AS relevance FROM poems WHERE `+scope.where()+`
+		ORDER BY `+order+`, poems.id
+		LIMIT `+args.add(MAX_SEARCH_RESULTS)+`;`,
+		*args...,
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	poems := make([]db_models.Poem, len(rankedPoems))
+	if ranks == nil {
+		ranks = make(map[string]float64)
+		for _, poem := range rankedPoems {
+			ranks[poem.Id] = poem.Relevance
+		}
+	}
+	for i := range rankedPoems {
+		poems[i] = rankedPoems[i].Poem
+	}
+	return poems, ranks, scope, nil
+}
+
+// Finds the users matching a search and applies the search's filters
+// and order to them. The period of a search applies to when users joined.
+// The relevance of the users and the scope of the search are returned
+// with them.
+func searchPeople(db *sqlx.DB, filters *searchFilters) ([]db_models.User, map[string]float64, *searchScope, error) {
+	scope, relevance, ranks, err := getSearchMatchScope(search.DOCUMENT_USER, "users", filters)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(filters.ViewerId) > 0 {
+		scope.Conditions = append(
+			scope.Conditions,
+			getUnblockedUserCondition("users.id", scope.Args.add(filters.ViewerId)),
+		)
+	}
+	if !filters.From.IsZero() {
+		scope.Conditions = append(scope.Conditions, "users.created_on>="+scope.Args.add(filters.From))
+	}
+	if !filters.To.IsZero() {
+		scope.Conditions = append(scope.Conditions, "users.created_on<"+scope.Args.add(filters.To))
+	}
+	order := relevance + " DESC"
+	switch filters.Sort {
+	case SEARCH_SORT_NEWEST:
+		order = "users.created_on DESC"
+	case SEARCH_SORT_OLDEST:
+		order = "users.created_on ASC"
+	}
+	args := scope.args()
+	rankedUsers := []struct {
+		db_models.User
+		Relevance float64 `db:"relevance"`
+	}{}
+	err = db.Select(
+		&rankedUsers,
+		`SELECT *, `+relevance+` AS relevance FROM users WHERE `+scope.where()+`
+		ORDER BY `+order+`, users.id
+		LIMIT `+args.add(MAX_SEARCH_RESULTS)+`;`,
+		*args...,
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	users := make([]db_models.User, len(rankedUsers))
+	if ranks == nil {
+		ranks = make(map[string]float64)
+		for _, user := range rankedUsers {
+			ranks[user.Id] = user.Relevance
+		}
+	}
+	for i := range rankedUsers {
+		users[i] = rankedUsers[i].User
+	}
+	return users, ranks, scope, nil
+}
+
+// Finds the comments on published poems matching a search by their text.
+// The scope of the search is returned with them.
+func searchComments(db *sqlx.DB, filters *searchFilters) ([]db_models.Comment, *searchScope, error) {
+	scope := &searchScope{
+		Table: "comments",
+		Conditions: []string{
+			"comments.is_deleted=FALSE",
+			"to_tsvector('english', comments.text) @@ websearch_to_tsquery('english', $1)",
+			"comments.poem_id IN (SELECT id FROM poems WHERE status='published')",
+		},
+		Args:       searchArgs{filters.Query},
+		IsComplete: true,
+	}
+	if len(filters.AuthorId) > 0 {
+		scope.Conditions = append(scope.Conditions, "comments.user_id="+scope.Args.add(filters.AuthorId))
+	}
+	if len(filters.ViewerId) > 0 {
+		scope.Conditions = append(
+			scope.Conditions,
+			getUnblockedUserCondition("comments.user_id", scope.Args.add(filters.ViewerId)),
+		)
+	}
+	if !filters.From.IsZero() {
+		scope.Conditions = append(scope.Conditions, "comments.created_on>="+scope.Args.add(filters.From))
+	}
+	if !filters.To.IsZero() {
+		scope.Conditions = append(scope.Conditions, "comments.created_on<"+scope.Args.add(filters.To))
+	}
+	likesCount := "(SELECT COUNT(*) FROM comments_likes WHERE comments_likes.comment_id=comments.id)"
+	if filters.MinLikes > 0 {
+		scope.Conditions = append(scope.Conditions, likesCount+">="+scope.Args.add(filters.MinLikes))
+	}
+	rank := "ts_rank_cd(to_tsvector('english', comments.text), websearch_to_tsquery('english', $1))"
+	order := rank + " DESC, comments.created_on DESC"
+	switch filters.Sort {
+	case SEARCH_SORT_NEWEST:
+		order = "comments.created_on DESC"
+	case SEARCH_SORT_OLDEST:
+		order = "comments.created_on ASC"
+	case SEARCH_SORT_LIKES:
+		order = likesCount + " DESC, " + rank + " DESC"
+	}
+	args := scope.args()
+	comments := []db_models.Comment{}
+	err := db.Select(
+		&comments,
+		`SELECT * FROM comments WHERE `+scope.where()+`
+		ORDER BY `+order+`, comments.id
+		LIMIT `+args.add(MAX_SEARCH_RESULTS)+`;`,
+		*args...,
+	)
+	return comments, scope, err
+}
+
+// Finds the tags that start with or resemble a search's query, with
+// the number of published poems using them. The scope of the search
+// is returned with them.
+func searchTags(db *sqlx.DB, filters *searchFilters, size int) ([]response_models.TagCount, *searchScope, error) {
+	query := strings.ToLower(filters.Query)
+	scope := &searchScope{
+		Table:      "tags",
+		Conditions: []string{"(tags.name LIKE $2 OR $1 <% tags.name)"},
+		Args:       searchArgs{query, utils.EscapeLikePattern(query) + "%"},
+		IsComplete: true,
+	}
+	order := "(tags.name LIKE $2) DESC, word_similarity($1, tags.name) DESC, poems_count DESC"
+	if filters.Sort != SEARCH_SORT_RELEVANCE {
+		order = "poems_count DESC"
+	}
+	args := scope.args()
+	tags := []response_models.TagCount{}
+	err := db.Select(
+		&tags,
+		`SELECT tags.name, COUNT(poems.id) AS poems_count FROM tags
+		LEFT JOIN poems_tags ON poems_tags.tag_id=tags.id
+		LEFT JOIN poems ON poems.id=poems_tags.poem_id AND poems.status='published'
+		WHERE `+scope.where()+`
+		GROUP BY tags.name
+		ORDER BY `+order+`, tags.name
+		LIMIT `+args.add(size)+`;`,
+		*args...,
+	)
+	return tags, scope, err
+}
+
+// Creates the search result of a user, with their rank and the
+// snippets of their name and bio highlighting the query's matches.
+func getUserSearchResult(
+	db *sqlx.DB,
+	user *db_models.User,
+	query string,
+	rank float64,
+	authToken *utils.AuthToken,
+) (*response_models.UserSearchResult, error) {
+	isFollowingUser := false
+	if authToken != nil {
+		userFollowing := &db_models.UserFollowing{}
+		err := db.Get(
+			userFollowing,
+			"SELECT * FROM users_followings WHERE follower_id=$1 AND following_id=$2;",
+			authToken.UserId,
+			user.Id,
+		)
+		isFollowingUser = err == nil
+	}
+	// the text is escaped so that the highlights' only markup is their own
+	match := &userSearchMatch{}
+	err := db.Get(
+		match,
+		`SELECT ts_headline('english', $2::text, websearch_to_tsquery('english', $1), $4)
+				AS name_highlight,
+			ts_headline('english', $3::text, websearch_to_tsquery('english', $1), $4)
+				AS bio_highlight;`,
+		query,
+		html.EscapeString(user.Name),
+		html.EscapeString(user.Bio),
+		utils.SearchHeadlineOptions,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &response_models.UserSearchResult{
+		UserMin: response_models.UserMin{
+			Id:             user.Id,
+			Name:           user.Name,
+			Handle:         user.Handle,
+			ProfilePhotoId: user.ProfilePhotoId,
+			IsFollowing:    isFollowingUser,
+		},
+		NameHighlight: match.NameHighlight,
+		BioHighlight:  match.BioHighlight,
+		Rank:          rank,
+	}, nil
+}
+
+// Counts the authors, tags and publishing years of all the poems matching a search.
+func getPoemsSearchFacets(db *sqlx.DB, scope *searchScope) (gin.H, error) {
+	authorsCounts := []struct {
+		UserId string `db:"user_id"`
+		Count  int    `db:"count"`
+	}{}
+	args := scope.args()
+	err := db.Select(
+		&authorsCounts,
+		`SELECT poems.user_id, COUNT(*) AS count FROM poems
+		WHERE `+scope.where()+`
+		GROUP BY poems.user_id
+		ORDER BY count DESC, poems.user_id
+		LIMIT `+args.add(MAX_SEARCH_FACET_VALUES)+`;`,
+		*args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	authors := make([]response_models.AuthorFacet, len(authorsCounts))
+	for i, authorCount := range authorsCounts {
+		user := &db_models.User{}
+		err = db.Get(user, "SELECT * FROM users WHERE id=$1;", authorCount.UserId)
+		if err != nil {
+			return nil, errors.New("Failed to find poem creator.")
+		}
+		authors[i] = response_models.AuthorFacet{
+			User: response_models.UserMin{
+				Id:             user.Id,
+				Name:           user.Name,
+				Handle:         user.Handle,
+				ProfilePhotoId: user.ProfilePhotoId,
+			},
+			Count: authorCount.Count,
+		}
+	}
+	years := []response_models.YearFacet{}
+	err = db.Select(
+		&years,
+		`SELECT date_part('year', poems.publish_at AT TIME ZONE 'UTC')::INT AS year, COUNT(*) AS count
+		FROM poems WHERE `+scope.where()+`
+		GROUP BY year
+		ORDER BY year DESC;`,
+		scope.Args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	args = scope.args()
+	tags := []response_models.TagCount{}
+	err = db.Select(
+		&tags,
+		`SELECT tags.name, COUNT(*) AS poems_count FROM poems_tags
+		INNER JOIN tags ON tags.id=poems_tags.tag_id
+		WHERE poems_tags.poem_id IN (SELECT poems.id FROM poems WHERE `+scope.where()+`)
+		GROUP BY tags.name
+		ORDER BY poems_count DESC, tags.name
+		LIMIT `+args.add(MAX_SEARCH_FACET_VALUES)+`;`,
+		*args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"authors": authors,
+		"tags":    tags,
+		"years":   years,
+	}, nil
+}
+
+// Retrieves a list of poems that match a given query, with the most
+// relevant first. Matches in titles weigh more than matches in verses
+// and titles are also matched by prefix and by similar spelling.
+func FindPoems(c *gin.Context) {
+	query, ok := getSearchQuery(c, MIN_POEMS_QUERY_LENGTH)
+	if !ok {
+		return
+	}
+	language, err := getSearchLanguageFilter(c)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 	pageSpec, err := utils.GetPageSpec(c, true)
@@ -33,161 +656,366 @@ func FindPoems(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	poems := []db_models.Poem{}
-	err = db.Select(
-		&poems,
-		`SELECT * FROM poems WHERE
-		to_tsvector(title || ' ' || text) @@ websearch_to_tsquery('english', $1)
-		;`,
-		query,
-	)
+	filters := &searchFilters{
+		Query:    query,
+		Language: language,
+		Sort:     SEARCH_SORT_RELEVANCE,
+	}
+	if authToken != nil {
+		filters.ViewerId = authToken.UserId
+	}
+	poems, ranks, _, err := searchPoems(db, filters)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
-	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
-	for i, pagePoem := range pagePoems {
-		user := &db_models.User{}
-		err = db.Get(
-			user,
-			"SELECT * FROM users WHERE id=$1;",
-			pagePoem.UserId,
-		)
+	pagePoemsObjs := make([]response_models.PoemSearchResult, len(pagePoems))
+	for i := range pagePoems {
+		poemObj, err := getPoemSearchResult(db, &pagePoems[i], query, ranks[pagePoems[i].Id], authToken)
 		if err != nil {
-			continue
-		}
-		repliesCount := 0
-		err = db.Select(
-			&repliesCount,
-			"SELECT COUNT(*) AS replies_count FROM comments WHERE poem_id=$1 AND comment_id=$2",
-			pagePoem.Id,
-			"",
-		)
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pagePoemsObjs[i] = *poemObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pagePoemsObjs,
+		},
+	)
+}
+
+// Retrieves a list of users that match a given query, with the most
+// relevant first. Matches in names weigh more than matches in bios and
+// names and handles are also matched by prefix and by similar spelling.
+func FindPeople(c *gin.Context) {
+	query, ok := getSearchQuery(c, MIN_PEOPLE_QUERY_LENGTH)
+	if !ok {
+		return
+	}
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	filters := &searchFilters{
+		Query: query,
+		Sort:  SEARCH_SORT_RELEVANCE,
+	}
+	if authToken != nil {
+		filters.ViewerId = authToken.UserId
+	}
+	users, ranks, _, err := searchPeople(db, filters)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	pageUsers, err := utils.ExtractPage(users, *pageSpec)
+	pageUsersObjs := make([]response_models.UserSearchResult, len(pageUsers))
+	for i := range pageUsers {
+		userObj, err := getUserSearchResult(db, &pageUsers[i], query, ranks[pageUsers[i].Id], authToken)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		likesCount := 0
-		err = db.Select(
-			&likesCount,
-			"SELECT COUNT(*) AS likes_count FROM poems_likes WHERE poem_id=$1;",
-			pagePoems[i].Id,
-		)
+		pageUsersObjs[i] = *userObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageUsersObjs,
+		},
+	)
+}
+
+// Searches poems, people, tags and comments at once with optional filters
+// and sort order, returning the results grouped by type with the number of
+// results of each type and facets of the matching poems. Counts are lower
+// bounds when a search backend other than the database's limits the matches.
+// Pages after or before a result can only be retrieved when searching a
+// single type.
+func Search(c *gin.Context) {
+	types := make(map[string]bool)
+	for _, searchType := range strings.Split(c.DefaultQuery("type", "poems,people,tags,comments"), ",") {
+		switch searchType {
+		case SEARCH_TYPE_POEMS, SEARCH_TYPE_PEOPLE, SEARCH_TYPE_TAGS, SEARCH_TYPE_COMMENTS:
+			types[searchType] = true
+		default:
+			c.JSON(200, gin.H{"success": false, "message": "Invalid search type."})
+			return
+		}
+	}
+	minLength := MIN_PEOPLE_QUERY_LENGTH
+	if types[SEARCH_TYPE_POEMS] || types[SEARCH_TYPE_COMMENTS] {
+		minLength = MIN_POEMS_QUERY_LENGTH
+	}
+	query, ok := getSearchQuery(c, minLength)
+	if !ok {
+		return
+	}
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// an anchor is the id of a result of a single type
+	if len(types) > 1 && (len(pageSpec.After) > 0 || len(pageSpec.Before) > 0) {
+		c.JSON(200, gin.H{"success": false, "message": "Page anchors need a single search type."})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	filters, err := getSearchFilters(c, db, query)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if authToken != nil {
+		filters.ViewerId = authToken.UserId
+	}
+	results := gin.H{}
+	typesCounts := make(map[string]int)
+	// counts of types whose matches are limited to the most relevant are lower bounds
+	typesAreExact := make(map[string]bool)
+	facets := gin.H{"types": typesCounts, "typesAreExact": typesAreExact}
+	if types[SEARCH_TYPE_POEMS] {
+		poems, ranks, scope, err := searchPoems(db, filters)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		isLiked, isFollowingUser := false, false
-		if authToken != nil {
-			poemLike := &db_models.PoemLike{}
-			err = db.Get(
-				poemLike,
-				"SELECT * FROM poems_likes WHERE poem_id=$1 AND user_id=$2;",
-				pagePoems[i].Id,
-				authToken.UserId,
-			)
-			isLiked = err == nil
-			userFollowing := &db_models.UserFollowing{}
-			err = db.Get(
-				userFollowing,
-				"SELECT * FROM users_followings WHERE follower_id=$1 AND following_id=$2;",
-				authToken.UserId,
-				pagePoem.UserId,
-			)
-			isFollowingUser = err == nil
-		}
-		verses := []string{}
-		err = json.Unmarshal([]byte(pagePoems[i].Text), &verses)
+		pagePoems, err := utils.ExtractPage(poems, *pageSpec)
+		pagePoemsObjs := make([]response_models.PoemSearchResult, len(pagePoems))
+		for i := range pagePoems {
+			poemObj, err := getPoemSearchResult(db, &pagePoems[i], query, ranks[pagePoems[i].Id], authToken)
+			if err != nil {
+				c.JSON(200, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+			pagePoemsObjs[i] = *poemObj
+		}
+		poemsFacets, err := getPoemsSearchFacets(db, scope)
 		if err != nil {
 			c.JSON(200, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-		pagePoemsObjs[i] = response_models.Poem{
-			Id: pagePoems[i].Id,
-			User: response_models.UserMin{
-				Id:             user.Id,
-				Name:           user.Name,
-				ProfilePhotoId: user.ProfilePhotoId,
-				IsFollowing:    isFollowingUser,
-			},
-			Title:         pagePoems[i].Title,
-			PublishedOn:   pagePoems[i].CreatedOn.Format(time.RFC3339),
-			Verses:        verses,
-			CommentsCount: repliesCount,
-			LikesCount:    likesCount,
-			IsLiked:       isLiked,
+		for name, facet := range poemsFacets {
+			facets[name] = facet
+		}
+		results[SEARCH_TYPE_POEMS] = pagePoemsObjs
+		typesCounts[SEARCH_TYPE_POEMS], err = scope.count(db)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		typesAreExact[SEARCH_TYPE_POEMS] = scope.IsComplete
+	}
+	if types[SEARCH_TYPE_PEOPLE] {
+		users, ranks, scope, err := searchPeople(db, filters)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pageUsers, err := utils.ExtractPage(users, *pageSpec)
+		pageUsersObjs := make([]response_models.UserSearchResult, len(pageUsers))
+		for i := range pageUsers {
+			userObj, err := getUserSearchResult(db, &pageUsers[i], query, ranks[pageUsers[i].Id], authToken)
+			if err != nil {
+				c.JSON(200, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+			pageUsersObjs[i] = *userObj
 		}
+		results[SEARCH_TYPE_PEOPLE] = pageUsersObjs
+		typesCounts[SEARCH_TYPE_PEOPLE], err = scope.count(db)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		typesAreExact[SEARCH_TYPE_PEOPLE] = scope.IsComplete
 	}
+	if types[SEARCH_TYPE_TAGS] {
+		tags, scope, err := searchTags(db, filters, pageSpec.Span)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		results[SEARCH_TYPE_TAGS] = tags
+		typesCounts[SEARCH_TYPE_TAGS], err = scope.count(db)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		typesAreExact[SEARCH_TYPE_TAGS] = scope.IsComplete
+	}
+	if types[SEARCH_TYPE_COMMENTS] {
+		comments, scope, err := searchComments(db, filters)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pageComments, err := utils.ExtractPage(comments, *pageSpec)
+		pageCommentsObjs := make([]response_models.Comment, len(pageComments))
+		for i := range pageComments {
+			commentObj, err := getCommentObj(db, &pageComments[i], authToken)
+			if err != nil {
+				c.JSON(200, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+			pageCommentsObjs[i] = *commentObj
+		}
+		results[SEARCH_TYPE_COMMENTS] = pageCommentsObjs
+		typesCounts[SEARCH_TYPE_COMMENTS], err = scope.count(db)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		typesAreExact[SEARCH_TYPE_COMMENTS] = scope.IsComplete
+	}
+	results["facets"] = facets
 	c.JSON(
 		200,
 		gin.H{
 			"success": true,
-			"data":    pagePoemsObjs,
+			"data":    results,
 		},
 	)
 }
 
-// Retrieves a list of users that match a given query.
-func FindPeople(c *gin.Context) {
-	query := strings.ReplaceAll(strings.Trim(c.Query("q"), " "), "'", "")
-	if len(query) < 3 {
+// Retrieves the people and poem titles that start with or resemble a
+// partially typed query, with prefix matches first. People who have a
+// block with the user typing and their poems are left out.
+func GetAutocompleteSuggestions(c *gin.Context) {
+	query := strings.ToLower(strings.TrimSpace(c.Query("q")))
+	if len(query) < 1 {
 		c.JSON(200, gin.H{"success": false, "message": "Query is too short."})
 		return
 	}
-	if strings.Count(query, "\"")%2 != 0 {
-		c.JSON(200, gin.H{"success": false, "message": "Unequal number of quotes."})
+	size, err := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if err != nil || size < 1 {
+		c.JSON(200, gin.H{"success": false, "message": "Invalid limit."})
 		return
 	}
-	pageSpec, err := utils.GetPageSpec(c, true)
-	if err != nil {
-		c.JSON(200, gin.H{"success": false, "message": err.Error()})
-		return
+	if size > MAX_AUTOCOMPLETE_SIZE {
+		size = MAX_AUTOCOMPLETE_SIZE
 	}
 	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	viewerId := ""
+	if authToken != nil {
+		viewerId = authToken.UserId
+	}
 	db, err := utils.GetDBConnection()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	users := []db_models.User{}
+	prefix := utils.EscapeLikePattern(query) + "%"
+	people := []response_models.UserMin{}
 	err = db.Select(
-		&users,
-		`SELECT * FROM users WHERE
-		to_tsvector(name || ' ' || bio) @@ websearch_to_tsquery('english', $1)
-		;`,
+		&people,
+		`SELECT id, name, handle, profile_photo_id FROM users
+		WHERE is_active=TRUE AND `+getUnblockedUserCondition("id", "$4")+` AND (
+			lower(handle) LIKE $2 OR
+			lower(name) LIKE $2 OR
+			lower(name) LIKE '% ' || $2 OR
+			$1 <% lower(name) OR
+			$1 <% lower(handle)
+		)
+		ORDER BY (lower(handle) LIKE $2 OR lower(name) LIKE $2) DESC,
+			GREATEST(word_similarity($1, lower(name)), word_similarity($1, lower(handle))) DESC,
+			name, id
+		LIMIT $3;`,
 		query,
+		prefix,
+		size,
+		viewerId,
 	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	pageUsers, err := utils.ExtractPage(users, *pageSpec)
-	pageUsersObjs := make([]response_models.UserMin, len(pageUsers))
-	for i, pageUser := range pageUsers {
-		isFollowingUser := false
-		if authToken != nil {
-			userFollowing := &db_models.UserFollowing{}
-			err = db.Get(
-				userFollowing,
-				"SELECT * FROM users_followings WHERE follower_id=$1 AND following_id=$2;",
-				authToken.UserId,
-				pageUser.Id,
-			)
-			isFollowingUser = err == nil
-		}
-		pageUsersObjs[i] = response_models.UserMin{
-			Id:             pageUser.Id,
-			Name:           pageUser.Name,
-			ProfilePhotoId: pageUser.ProfilePhotoId,
-			IsFollowing:    isFollowingUser,
-		}
+	poems := []response_models.PoemTitle{}
+	err = db.Select(
+		&poems,
+		`SELECT id, title FROM poems
+		WHERE status='published' AND title<>'' AND `+getUnblockedUserCondition("user_id", "$4")+` AND (
+			lower(title) LIKE $2 OR
+			lower(title) LIKE '% ' || $2 OR
+			$1 <% lower(title)
+		)
+		ORDER BY (lower(title) LIKE $2) DESC, word_similarity($1, lower(title)) DESC,
+			publish_at DESC, id
+		LIMIT $3;`,
+		query,
+		prefix,
+		size,
+		viewerId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
 	}
 	c.JSON(
 		200,
 		gin.H{
 			"success": true,
-			"data":    pageUsersObjs,
+			"data": gin.H{
+				"people": people,
+				"poems":  poems,
+			},
 		},
 	)
 }
+
+// Rebuilds the search index from the database while the server is running.
+func ReindexSearch(c *gin.Context) {
+	var jsonBody request_models.SearchReindexForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	if !utils.IsAdmin(jsonBody.UserId) {
+		c.JSON(200, gin.H{"success": false, "message": "Only admins can rebuild the search index."})
+		return
+	}
+	index, err := search.GetIndex()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	count, err := search.Reindex(db, index)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": gin.H{"documentsCount": count}})
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"io"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// The interval between keep-alive messages on an event stream.
+const EVENT_STREAM_PING_INTERVAL = 20 * time.Second
+
+// Retrieves the users a user follows and the users who have a block with
+// them. The connection to the database is closed before the stream starts
+// so that it isn't held for as long as the stream is open.
+func getStreamUsers(userId string) (map[string]bool, map[string]bool, error) {
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+	followingsIds := []string{}
+	err = db.Select(
+		&followingsIds,
+		"SELECT following_id FROM users_followings WHERE follower_id=$1;",
+		userId,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	followings := make(map[string]bool, len(followingsIds))
+	for _, followingId := range followingsIds {
+		followings[followingId] = true
+	}
+	blockedIds := []string{}
+	err = db.Select(
+		&blockedIds,
+		`SELECT blocked_id FROM users_blocks WHERE blocker_id=$1
+		UNION
+		SELECT blocker_id FROM users_blocks WHERE blocked_id=$1;`,
+		userId,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	blocks := make(map[string]bool, len(blockedIds))
+	for _, blockedId := range blockedIds {
+		blocks[blockedId] = true
+	}
+	return followings, blocks, nil
+}
+
+// Streams new poems by followed users, new comments on the poem being
+// viewed and new notifications to the current user as Server-Sent Events.
+// Comments by users who have a block with the current user are left out.
+func GetEventStream(c *gin.Context) {
+	poemId := c.DefaultQuery("poemId", "")
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	followings, blocks, err := getStreamUsers(authToken.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	subscription := events.Subscribe(func(event events.Event) bool {
+		switch event.Type {
+		case events.EventPoemCreated:
+			return followings[event.AuthorId]
+		case events.EventCommentCreated:
+			return len(poemId) > 0 && event.PoemId == poemId && !blocks[event.AuthorId]
+		case events.EventNotificationCreated:
+			return event.UserId == authToken.UserId
+		}
+		return false
+	})
+	defer events.Unsubscribe(subscription)
+	ticker := time.NewTicker(EVENT_STREAM_PING_INTERVAL)
+	defer ticker.Stop()
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-subscription.Events:
+			c.SSEvent(event.Type, event)
+			return true
+		case currentTime := <-ticker.C:
+			c.SSEvent("ping", currentTime.UTC().Format(time.RFC3339))
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// The maximum number of users suggested to a user to follow.
+	MAX_PEOPLE_SUGGESTIONS = 50
+	// The weight of each followed user who follows a suggested user.
+	SUGGESTION_FRIEND_WEIGHT = 3
+	// The weight of each of a suggested user's poems the user liked.
+	SUGGESTION_LIKED_AUTHOR_WEIGHT = 2
+	// The weight of each poem both the user and a suggested user liked.
+	SUGGESTION_CO_LIKE_WEIGHT = 1
+)
+
+// Represents the signals a user is suggested to another user with.
+type personSuggestion struct {
+	UserId       string
+	FriendsCount int
+	FriendId     string
+	LikedCount   int
+	CoLikedCount int
+	Score        int
+}
+
+// Represents the number of times a signal links a user to another user.
+type suggestionSignal struct {
+	UserId string `db:"user_id"`
+	Count  int    `db:"signal_count"`
+	ViaId  string `db:"via_id"`
+}
+
+// The condition of users that can be suggested to the user with id $1.
+const suggestableUserCondition = `%[1]s<>$1
+	AND %[1]s NOT IN (SELECT following_id FROM users_followings WHERE follower_id=$1)
+	AND %[1]s NOT IN (SELECT blocked_id FROM users_blocks WHERE blocker_id=$1)
+	AND %[1]s NOT IN (SELECT blocker_id FROM users_blocks WHERE blocked_id=$1)`
+
+// Finds the users a user could follow, from the users followed by the users
+// they follow, the authors of the poems they liked and the users who liked
+// the same poems as them.
+func getPeopleSuggestions(db *sqlx.DB, userId string) ([]personSuggestion, error) {
+	friendsSignals := []suggestionSignal{}
+	err := db.Select(
+		&friendsSignals,
+		`SELECT friends_followings.following_id AS user_id,
+			COUNT(*) AS signal_count, MIN(friends_followings.follower_id) AS via_id
+		FROM users_followings
+		INNER JOIN users_followings AS friends_followings
+			ON friends_followings.follower_id=users_followings.following_id
+		WHERE users_followings.follower_id=$1 AND `+
+			fmt.Sprintf(suggestableUserCondition, "friends_followings.following_id")+`
+		GROUP BY friends_followings.following_id
+		ORDER BY signal_count DESC LIMIT $2;`,
+		userId,
+		MAX_PEOPLE_SUGGESTIONS,
+	)
+	if err != nil {
+		return nil, err
+	}
+	likedSignals := []suggestionSignal{}
+	err = db.Select(
+		&likedSignals,
+		`SELECT poems.user_id, COUNT(*) AS signal_count, '' AS via_id
+		FROM poems_likes
+		INNER JOIN poems ON poems.id=poems_likes.poem_id
+		WHERE poems_likes.user_id=$1 AND poems.status='published' AND `+
+			fmt.Sprintf(suggestableUserCondition, "poems.user_id")+`
+		GROUP BY poems.user_id
+		ORDER BY signal_count DESC LIMIT $2;`,
+		userId,
+		MAX_PEOPLE_SUGGESTIONS,
+	)
+	if err != nil {
+		return nil, err
+	}
+	coLikedSignals := []suggestionSignal{}
+	err = db.Select(
+		&coLikedSignals,
+		`SELECT others_likes.user_id, COUNT(*) AS signal_count, '' AS via_id
+		FROM poems_likes
+		INNER JOIN poems_likes AS others_likes ON others_likes.poem_id=poems_likes.poem_id
+		WHERE poems_likes.user_id=$1 AND `+
+			fmt.Sprintf(suggestableUserCondition, "others_likes.user_id")+`
+		GROUP BY others_likes.user_id
+		ORDER BY signal_count DESC LIMIT $2;`,
+		userId,
+		MAX_PEOPLE_SUGGESTIONS,
+	)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := make(map[string]*personSuggestion)
+	getSuggestion := func(suggestedId string) *personSuggestion {
+		if _, ok := suggestions[suggestedId]; !ok {
+			suggestions[suggestedId] = &personSuggestion{UserId: suggestedId}
+		}
+		return suggestions[suggestedId]
+	}
+	for _, signal := range friendsSignals {
+		suggestion := getSuggestion(signal.UserId)
+		suggestion.FriendsCount = signal.Count
+		suggestion.FriendId = signal.ViaId
+		suggestion.Score += signal.Count * SUGGESTION_FRIEND_WEIGHT
+	}
+	for _, signal := range likedSignals {
+		suggestion := getSuggestion(signal.UserId)
+		suggestion.LikedCount = signal.Count
+		suggestion.Score += signal.Count * SUGGESTION_LIKED_AUTHOR_WEIGHT
+	}
+	for _, signal := range coLikedSignals {
+		suggestion := getSuggestion(signal.UserId)
+		suggestion.CoLikedCount = signal.Count
+		suggestion.Score += signal.Count * SUGGESTION_CO_LIKE_WEIGHT
+	}
+	sortedSuggestions := make([]personSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		sortedSuggestions = append(sortedSuggestions, *suggestion)
+	}
+	sort.SliceStable(sortedSuggestions, func(i, j int) bool {
+		if sortedSuggestions[i].Score != sortedSuggestions[j].Score {
+			return sortedSuggestions[i].Score > sortedSuggestions[j].Score
+		}
+		return sortedSuggestions[i].UserId < sortedSuggestions[j].UserId
+	})
+	if len(sortedSuggestions) > MAX_PEOPLE_SUGGESTIONS {
+		sortedSuggestions = sortedSuggestions[:MAX_PEOPLE_SUGGESTIONS]
+	}
+	return sortedSuggestions, nil
+}
+
+// Creates the text explaining why a user is suggested,
+// from the signal that contributed the most to the suggestion.
+func getSuggestionReason(db *sqlx.DB, suggestion *personSuggestion) (string, error) {
+	friendScore := suggestion.FriendsCount * SUGGESTION_FRIEND_WEIGHT
+	likedScore := suggestion.LikedCount * SUGGESTION_LIKED_AUTHOR_WEIGHT
+	if friendScore > 0 && friendScore >= likedScore {
+		friend := &db_models.User{}
+		err := db.Get(friend, "SELECT * FROM users WHERE id=$1;", suggestion.FriendId)
+		if err != nil {
+			return "", err
+		}
+		if suggestion.FriendsCount == 1 {
+			return "Followed by " + friend.Name + ".", nil
+		} else if suggestion.FriendsCount == 2 {
+			return "Followed by " + friend.Name + " and 1 other you follow.", nil
+		}
+		return fmt.Sprintf(
+			"Followed by %s and %d others you follow.",
+			friend.Name,
+			suggestion.FriendsCount-1,
+		), nil
+	}
+	if suggestion.LikedCount == 1 {
+		return "You liked their poem.", nil
+	} else if suggestion.LikedCount > 1 {
+		return fmt.Sprintf("You liked %d of their poems.", suggestion.LikedCount), nil
+	}
+	return "Likes the same poems as you.", nil
+}
+
+// Retrieves users the current user could follow.
+func GetPeopleSuggestions(c *gin.Context) {
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	suggestions, err := getPeopleSuggestions(db, authToken.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	users := make([]db_models.User, 0, len(suggestions))
+	suggestionsIndexes := make(map[string]int, len(suggestions))
+	for i, suggestion := range suggestions {
+		user := db_models.User{}
+		err = db.Get(
+			&user,
+			"SELECT * FROM users WHERE id=$1 AND is_active=TRUE;",
+			suggestion.UserId,
+		)
+		if err != nil {
+			continue
+		}
+		suggestionsIndexes[user.Id] = i
+		users = append(users, user)
+	}
+	pageUsers, err := utils.ExtractPage(users, *pageSpec)
+	pageSuggestionsObjs := make([]response_models.PersonSuggestion, len(pageUsers))
+	for i, pageUser := range pageUsers {
+		reason, err := getSuggestionReason(db, &suggestions[suggestionsIndexes[pageUser.Id]])
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pageSuggestionsObjs[i] = response_models.PersonSuggestion{
+			User: response_models.UserMin{
+				Id:             pageUser.Id,
+				Name:           pageUser.Name,
+				Handle:         pageUser.Handle,
+				ProfilePhotoId: pageUser.ProfilePhotoId,
+			},
+			Reason: reason,
+		}
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageSuggestionsObjs,
+		},
+	)
+}
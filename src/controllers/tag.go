@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// The maximum number of tags returned by the tag list endpoints.
+	MAX_TAGS_LIST_SIZE = 50
+	// The maximum time window in hours for trending tags.
+	MAX_TRENDING_TAGS_HOURS = 24 * 30
+)
+
+// Replaces the tags attached to a poem.
+func setPoemTags(tx *sql.Tx, poemId string, tags []string, currentTime time.Time) error {
+	_, err := tx.Exec("DELETE FROM poems_tags WHERE poem_id=$1;", poemId)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		_, err = tx.Exec(
+			`INSERT INTO tags (id, name, created_on)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (name) DO NOTHING;`,
+			uuid.New().String(),
+			tag,
+			currentTime,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO poems_tags (id, poem_id, tag_id, created_on)
+			SELECT $1, $2, id, $3 FROM tags WHERE name=$4;`,
+			uuid.New().String(),
+			poemId,
+			currentTime,
+			tag,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Retrieves the size of a tag list from a gin Context.
+func getTagsListSize(c *gin.Context) (int, error) {
+	size, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || size < 1 {
+		return 0, errors.New("Invalid limit.")
+	}
+	if size > MAX_TAGS_LIST_SIZE {
+		size = MAX_TAGS_LIST_SIZE
+	}
+	return size, nil
+}
+
+// Retrieves the published poems with a given tag.
+func GetTagPoems(c *gin.Context) {
+	tag, ok := utils.NormalizeTag(c.Param("name"))
+	if !ok {
+		c.JSON(200, gin.H{"success": false, "message": "Invalid tag."})
+		return
+	}
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	poems := []db_models.Poem{}
+	err = db.Select(
+		&poems,
+		`SELECT poems.* FROM poems
+		INNER JOIN poems_tags ON poems_tags.poem_id=poems.id
+		INNER JOIN tags ON tags.id=poems_tags.tag_id
+		WHERE tags.name=$1 AND poems.status='published'
+		ORDER BY poems.publish_at DESC;`,
+		tag,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	pagePoems, err := utils.ExtractPage(poems, *pageSpec)
+	pagePoemsObjs := make([]response_models.Poem, len(pagePoems))
+	for i := range pagePoems {
+		poemObj, err := getPoemObj(db, &pagePoems[i], authToken)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pagePoemsObjs[i] = *poemObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pagePoemsObjs,
+		},
+	)
+}
+
+// Retrieves the most used tags that start with a given prefix.
+func GetTagSuggestions(c *gin.Context) {
+	prefix, ok := utils.NormalizeTag(c.Query("q"))
+	if !ok {
+		c.JSON(200, gin.H{"success": false, "message": "Invalid tag."})
+		return
+	}
+	size, err := getTagsListSize(c)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	tags := []struct {
+		Name       string `db:"name" json:"name"`
+		PoemsCount int    `db:"poems_count" json:"poemsCount"`
+	}{}
+	err = db.Select(
+		&tags,
+		`SELECT tags.name, COUNT(poems.id) AS poems_count FROM tags
+		LEFT JOIN poems_tags ON poems_tags.tag_id=tags.id
+		LEFT JOIN poems ON poems.id=poems_tags.poem_id AND poems.status='published'
+		WHERE tags.name LIKE $1
+		GROUP BY tags.name
+		ORDER BY poems_count DESC, tags.name
+		LIMIT $2;`,
+		strings.ReplaceAll(prefix, "_", "\\_")+"%",
+		size,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    tags,
+		},
+	)
+}
+
+// Retrieves the tags used by the most poems published within a time window.
+func GetTrendingTags(c *gin.Context) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours < 1 || hours > MAX_TRENDING_TAGS_HOURS {
+		c.JSON(200, gin.H{"success": false, "message": "Invalid time window."})
+		return
+	}
+	size, err := getTagsListSize(c)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	currentTime := time.Now().UTC()
+	tags := []struct {
+		Name         string `db:"name" json:"name"`
+		PoemsCount   int    `db:"poems_count" json:"poemsCount"`
+		AuthorsCount int    `db:"authors_count" json:"authorsCount"`
+	}{}
+	err = db.Select(
+		&tags,
+		`SELECT tags.name,
+			COUNT(poems.id) AS poems_count,
+			COUNT(DISTINCT poems.user_id) AS authors_count
+		FROM tags
+		INNER JOIN poems_tags ON poems_tags.tag_id=tags.id
+		INNER JOIN poems ON poems.id=poems_tags.poem_id
+		WHERE poems.status='published' AND poems.publish_at>$1 AND poems.publish_at<=$2
+		GROUP BY tags.name
+		ORDER BY authors_count DESC, poems_count DESC, tags.name
+		LIMIT $3;`,
+		currentTime.Add(-time.Duration(hours)*time.Hour),
+		currentTime,
+		size,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    tags,
+		},
+	)
+}
@@ -8,9 +8,11 @@ import (
 
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
 	imagekit "github.com/B3zaleel/imagekit-go"
 	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
 )
 
 // Retrieves information about a given user.
@@ -28,71 +30,16 @@ func GetUser(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": "Failed to find user."})
 		return
 	}
-	isFollowingUser := false
-	userEmail := ""
-	if authToken != nil {
-		userFollowing := &db_models.UserFollowing{}
-		err = db.Get(
-			userFollowing,
-			"SELECT * FROM users_followings WHERE follower_id=$1 AND following_id=$2;",
-			authToken.UserId,
-			userId,
-		)
-		isFollowingUser = err == nil
-		if authToken.UserId == userId {
-			userEmail = user.Email
-		}
+	userData, err := getUserData(db, user, authToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
 	}
-	poemsCount := 0
-	db.Select(
-		&poemsCount,
-		"SELECT COUNT(*) AS poems_count FROM poems WHERE user_id=$1;",
-		userId,
-	)
-	poemLikesCount := 0
-	db.Select(
-		&poemLikesCount,
-		"SELECT COUNT(*) AS poems_likes_count FROM poems_likes WHERE user_id=$1;",
-		userId,
-	)
-	commentsCount := 0
-	db.Select(
-		&commentsCount,
-		"SELECT COUNT(*) AS comments_count FROM comments WHERE user_id=$1;",
-		userId,
-	)
-	followersCount := 0
-	db.Select(
-		&followersCount,
-		`SELECT COUNT(*) AS followers_count FROM users_followings
-		WHERE following_id=$1;`,
-		userId,
-	)
-	followingsCount := 0
-	db.Select(
-		&followingsCount,
-		`SELECT COUNT(*) AS followings_count FROM users_followings
-		WHERE follower_id=$1;`,
-		userId,
-	)
 	c.JSON(
 		200,
 		gin.H{
 			"success": true,
-			"data": gin.H{
-				"id":              user.Id,
-				"joined":          user.CreatedOn.UTC().Format(time.RFC3339),
-				"name":            user.Name,
-				"email":           userEmail,
-				"bio":             user.Bio,
-				"profilePhotoId":  user.ProfilePhotoId,
-				"followersCount":  followersCount,
-				"followingsCount": followingsCount,
-				"poemsCount":      poemsCount,
-				"likesCount":      poemLikesCount,
-				"commentsCount":   commentsCount,
-				"isFollowing":     isFollowingUser,
-			},
+			"data":    userData,
 		},
 	)
 }
@@ -210,6 +157,9 @@ func UpdateUser(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	user.Name = jsonBody.Name
+	user.Bio = jsonBody.Bio
+	search.SyncUser(user)
 	newAuthToken := &utils.AuthToken{
 		UserId:     user.Id,
 		Email:      jsonBody.Email,
@@ -261,6 +211,12 @@ func RemoveUser(c *gin.Context) {
 		)
 		return
 	}
+	poemsIds := []string{}
+	err = db.Select(&poemsIds, "SELECT id FROM poems WHERE user_id=$1;", jsonBody.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	tx, err := db.Begin()
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
@@ -275,7 +231,26 @@ func RemoveUser(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	// remove user's bookmarks and bookmarks of user's poems
+	_, err = tx.Exec(
+		`DELETE FROM bookmarks WHERE user_id=$1 OR poem_id IN (
+			SELECT id FROM poems WHERE user_id=$1
+		);`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	// remove user's connections
+	_, err = tx.Exec(
+		"DELETE FROM users_blocks WHERE blocker_id=$1 OR blocked_id=$1;",
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	_, err = tx.Exec(
 		"DELETE FROM users_followings WHERE follower_id=$1 OR following_id=$1;",
 		jsonBody.UserId,
@@ -284,10 +259,160 @@ func RemoveUser(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	// remove replies to user's comments
+	// remove mentions of and by the user and mentions in user's poems
+	_, err = tx.Exec(
+		`DELETE FROM mentions WHERE user_id=$1 OR author_id=$1 OR poem_id IN (
+			SELECT id FROM poems WHERE user_id=$1
+		);`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove notifications for and by the user and about user's poems
+	_, err = tx.Exec(
+		`DELETE FROM notifications WHERE user_id=$1 OR actor_id=$1 OR poem_id IN (
+			SELECT id FROM poems WHERE user_id=$1
+		);`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove user's webhooks and their deliveries
+	_, err = tx.Exec(
+		`DELETE FROM webhook_deliveries WHERE webhook_id IN (
+			SELECT id FROM webhooks WHERE user_id=$1
+		);`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec("DELETE FROM webhooks WHERE user_id=$1;", jsonBody.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove user's handle history
+	_, err = tx.Exec("DELETE FROM handle_changes WHERE user_id=$1;", jsonBody.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove user's likes on comments
+	_, err = tx.Exec("DELETE FROM comments_likes WHERE user_id=$1;", jsonBody.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove comments on user's poems and all their replies
+	err = deleteComments(
+		tx,
+		`WITH RECURSIVE threads AS (
+			SELECT id FROM comments WHERE poem_id IN (
+				SELECT id FROM poems WHERE user_id=$1
+			)
+			UNION
+			SELECT comments.id FROM comments
+			INNER JOIN threads ON comments.comment_id=threads.id
+		)
+		SELECT id FROM threads`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// keep user's comments that other users replied to as tombstones
+	err = tombstoneComments(
+		tx,
+		`WITH RECURSIVE threads AS (
+			SELECT id AS root_id, id FROM comments WHERE user_id=$1
+			UNION
+			SELECT threads.root_id, comments.id FROM comments
+			INNER JOIN threads ON comments.comment_id=threads.id
+		)
+		SELECT threads.root_id FROM threads
+		INNER JOIN comments ON comments.id=threads.id
+		WHERE comments.user_id<>$1 AND comments.is_deleted=FALSE`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove user's other comments, whose replies are only user's own
+	err = deleteComments(
+		tx,
+		`WITH RECURSIVE threads AS (
+			SELECT id FROM comments WHERE user_id=$1 AND is_deleted=FALSE
+			UNION
+			SELECT comments.id FROM comments
+			INNER JOIN threads ON comments.comment_id=threads.id
+		)
+		SELECT id FROM threads`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	_, err = tx.Exec(
-		`DELETE FROM comments WHERE comment_id IN (
-			SELECT * FROM comments WHERE user_id=$1 AND comment_id=''
+		"UPDATE comments SET user_id=NULL WHERE user_id=$1;",
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove user's collections and the entries of user's poems in collections
+	err = removeCollectionsPoems(
+		tx,
+		`SELECT id FROM collections_poems WHERE collection_id IN (
+			SELECT id FROM collections WHERE user_id=$1
+		) OR poem_id IN (
+			SELECT id FROM poems WHERE user_id=$1
+		)`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec("DELETE FROM collections WHERE user_id=$1;", jsonBody.UserId)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove tags of user's poems
+	_, err = tx.Exec(
+		`DELETE FROM poems_tags WHERE poem_id IN (
+			SELECT id FROM poems WHERE user_id=$1
+		);`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	// remove revisions of user's poems
+	_, err = tx.Exec(
+		`DELETE FROM poem_revisions WHERE poem_id IN (
+			SELECT id FROM poems WHERE user_id=$1
+		);`,
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`DELETE FROM timelines WHERE user_id=$1 OR author_id=$1 OR poem_id IN (
+			SELECT id FROM poems WHERE user_id=$1
 		);`,
 		jsonBody.UserId,
 	)
@@ -295,8 +420,12 @@ func RemoveUser(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	// remove user's comments
-	_, err = tx.Exec("DELETE FROM comments WHERE user_id=$1;", jsonBody.UserId)
+	_, err = tx.Exec(
+		`DELETE FROM explore_rankings WHERE poem_id IN (
+			SELECT id FROM poems WHERE user_id=$1
+		);`,
+		jsonBody.UserId,
+	)
 	if err != nil {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
@@ -317,6 +446,10 @@ func RemoveUser(c *gin.Context) {
 		c.JSON(200, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	for _, poemId := range poemsIds {
+		search.Remove(search.DOCUMENT_POEM, poemId)
+	}
+	search.Remove(search.DOCUMENT_USER, jsonBody.UserId)
 	c.JSON(
 		200,
 		gin.H{
@@ -325,3 +458,85 @@ func RemoveUser(c *gin.Context) {
 		},
 	)
 }
+
+// Creates the response data of a user's profile.
+func getUserData(db *sqlx.DB, user *db_models.User, authToken *utils.AuthToken) (gin.H, error) {
+	var err error
+	isFollowingUser := false
+	userEmail := ""
+	if authToken != nil {
+		userFollowing := &db_models.UserFollowing{}
+		err = db.Get(
+			userFollowing,
+			"SELECT * FROM users_followings WHERE follower_id=$1 AND following_id=$2;",
+			authToken.UserId,
+			user.Id,
+		)
+		isFollowingUser = err == nil
+		if authToken.UserId == user.Id {
+			userEmail = user.Email
+		}
+	}
+	poemsCount := 0
+	err = db.Get(
+		&poemsCount,
+		"SELECT COUNT(*) AS poems_count FROM poems WHERE user_id=$1 AND status='published';",
+		user.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	poemLikesCount := 0
+	err = db.Get(
+		&poemLikesCount,
+		"SELECT COUNT(*) AS poems_likes_count FROM poems_likes WHERE user_id=$1;",
+		user.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	commentsCount := 0
+	err = db.Get(
+		&commentsCount,
+		"SELECT COUNT(*) AS comments_count FROM comments WHERE user_id=$1 AND is_deleted=FALSE;",
+		user.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	followersCount := 0
+	err = db.Get(
+		&followersCount,
+		`SELECT COUNT(*) AS followers_count FROM users_followings
+		WHERE following_id=$1;`,
+		user.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	followingsCount := 0
+	err = db.Get(
+		&followingsCount,
+		`SELECT COUNT(*) AS followings_count FROM users_followings
+		WHERE follower_id=$1;`,
+		user.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"id":              user.Id,
+		"joined":          user.CreatedOn.UTC().Format(time.RFC3339),
+		"name":            user.Name,
+		"handle":          user.Handle,
+		"email":           userEmail,
+		"bio":             user.Bio,
+		"profilePhotoId":  user.ProfilePhotoId,
+		"followersCount":  followersCount,
+		"followingsCount": followingsCount,
+		"poemsCount":      poemsCount,
+		"likesCount":      poemLikesCount,
+		"commentsCount":   commentsCount,
+		"isFollowing":     isFollowingUser,
+	}, nil
+}
@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/request_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/response_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// The maximum number of webhooks a user can register.
+const MAX_USER_WEBHOOKS = 10
+
+// Checks the event types a webhook subscribes to and removes duplicates.
+func getWebhookEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		return nil, errors.New("At least 1 event type is needed.")
+	}
+	uniqueEventTypes := []string{}
+	seen := make(map[string]bool)
+	for _, eventType := range eventTypes {
+		if !events.IsWebhookEventType(eventType) {
+			return nil, errors.New("Invalid event type.")
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			uniqueEventTypes = append(uniqueEventTypes, eventType)
+		}
+	}
+	return uniqueEventTypes, nil
+}
+
+// Creates the response object of a webhook.
+func getWebhookObj(webhook *db_models.Webhook) (*response_models.Webhook, error) {
+	eventTypes := []string{}
+	err := json.Unmarshal([]byte(webhook.EventTypes), &eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &response_models.Webhook{
+		Id:         webhook.Id,
+		Url:        webhook.Url,
+		EventTypes: eventTypes,
+		Scope:      webhook.Scope,
+		IsActive:   webhook.IsActive,
+		CreatedOn:  webhook.CreatedOn.UTC().Format(time.RFC3339),
+		UpdatedOn:  webhook.UpdatedOn.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// Retrieves the current user's webhooks.
+func GetWebhooks(c *gin.Context) {
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	webhooks := []db_models.Webhook{}
+	err = db.Select(
+		&webhooks,
+		"SELECT * FROM webhooks WHERE user_id=$1;",
+		authToken.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedOn.After(webhooks[j].CreatedOn)
+	})
+	pageWebhooks, err := utils.ExtractPage(webhooks, *pageSpec)
+	pageWebhooksObjs := make([]response_models.Webhook, len(pageWebhooks))
+	for i, pageWebhook := range pageWebhooks {
+		webhookObj, err := getWebhookObj(&pageWebhook)
+		if err != nil {
+			c.JSON(200, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		pageWebhooksObjs[i] = *webhookObj
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageWebhooksObjs,
+		},
+	)
+}
+
+// Registers a webhook for the current user. The secret its payloads
+// are signed with is only returned here.
+func AddWebhook(c *gin.Context) {
+	var jsonBody request_models.WebhookAddForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	scope := jsonBody.Scope
+	if len(scope) == 0 {
+		scope = db_models.WebhookScopeUser
+	}
+	if scope != db_models.WebhookScopeUser && scope != db_models.WebhookScopeAll {
+		c.JSON(200, gin.H{"success": false, "message": "Invalid webhook scope."})
+		return
+	}
+	if scope == db_models.WebhookScopeAll && !utils.IsAdmin(jsonBody.UserId) {
+		c.JSON(200, gin.H{"success": false, "message": "Only admins can receive all events."})
+		return
+	}
+	err = utils.ValidateWebhookUrl(jsonBody.Url)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	eventTypes, err := getWebhookEventTypes(jsonBody.EventTypes)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	eventTypesTxt, err := json.Marshal(eventTypes)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	webhooksCount := 0
+	err = db.Get(
+		&webhooksCount,
+		"SELECT COUNT(*) FROM webhooks WHERE user_id=$1;",
+		jsonBody.UserId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if webhooksCount >= MAX_USER_WEBHOOKS {
+		c.JSON(200, gin.H{"success": false, "message": "Too many webhooks."})
+		return
+	}
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	currentTime := time.Now().UTC()
+	webhook := &db_models.Webhook{
+		Id:         uuid.New().String(),
+		UserId:     jsonBody.UserId,
+		Url:        jsonBody.Url,
+		Secret:     secret,
+		EventTypes: string(eventTypesTxt),
+		Scope:      scope,
+		IsActive:   true,
+		CreatedOn:  currentTime,
+		UpdatedOn:  currentTime,
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`INSERT INTO webhooks (
+			id, user_id, url, secret, event_types, scope, is_active, created_on, updated_on
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+		webhook.Id,
+		webhook.UserId,
+		webhook.Url,
+		webhook.Secret,
+		webhook.EventTypes,
+		webhook.Scope,
+		webhook.IsActive,
+		webhook.CreatedOn,
+		webhook.UpdatedOn,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	webhookObj, err := getWebhookObj(webhook)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data": gin.H{
+				"webhook": webhookObj,
+				"secret":  secret,
+			},
+		},
+	)
+}
+
+// Edits the URL, event types or active status of a webhook.
+func UpdateWebhook(c *gin.Context) {
+	var jsonBody request_models.WebhookUpdateForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	webhook := &db_models.Webhook{}
+	err = db.Get(webhook, "SELECT * FROM webhooks WHERE id=$1;", jsonBody.WebhookId)
+	if err != nil || webhook.UserId != jsonBody.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find webhook."})
+		return
+	}
+	err = utils.ValidateWebhookUrl(jsonBody.Url)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	eventTypes, err := getWebhookEventTypes(jsonBody.EventTypes)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	eventTypesTxt, err := json.Marshal(eventTypes)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	webhook.Url = jsonBody.Url
+	webhook.EventTypes = string(eventTypesTxt)
+	webhook.IsActive = jsonBody.IsActive
+	webhook.UpdatedOn = time.Now().UTC()
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec(
+		`UPDATE webhooks SET
+			url=$1, event_types=$2, is_active=$3, updated_on=$4
+			WHERE id=$5;`,
+		webhook.Url,
+		webhook.EventTypes,
+		webhook.IsActive,
+		webhook.UpdatedOn,
+		webhook.Id,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	webhookObj, err := getWebhookObj(webhook)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    webhookObj,
+		},
+	)
+}
+
+// Removes a webhook and its deliveries.
+func RemoveWebhook(c *gin.Context) {
+	var jsonBody request_models.WebhookDeleteForm
+	err := c.ShouldBindJSON(&jsonBody)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(jsonBody.AuthToken)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if jsonBody.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "User id and auth token are a mismatch."})
+		return
+	}
+	webhook := &db_models.Webhook{}
+	err = db.Get(webhook, "SELECT * FROM webhooks WHERE id=$1;", jsonBody.WebhookId)
+	if err != nil || webhook.UserId != jsonBody.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find webhook."})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id=$1;", webhook.Id)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	_, err = tx.Exec("DELETE FROM webhooks WHERE id=$1;", webhook.Id)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    gin.H{},
+		},
+	)
+}
+
+// Retrieves the log of a webhook's deliveries, with the most recent first.
+func GetWebhookDeliveries(c *gin.Context) {
+	webhookId := c.Query("id")
+	pageSpec, err := utils.GetPageSpec(c, true)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	authToken, err := utils.DecodeAuthToken(c.Query("token"))
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	webhook := &db_models.Webhook{}
+	err = db.Get(webhook, "SELECT * FROM webhooks WHERE id=$1;", webhookId)
+	if err != nil || webhook.UserId != authToken.UserId {
+		c.JSON(200, gin.H{"success": false, "message": "Failed to find webhook."})
+		return
+	}
+	deliveries := []db_models.WebhookDelivery{}
+	err = db.Select(
+		&deliveries,
+		"SELECT * FROM webhook_deliveries WHERE webhook_id=$1;",
+		webhookId,
+	)
+	if err != nil {
+		c.JSON(200, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedOn.After(deliveries[j].CreatedOn)
+	})
+	pageDeliveries, err := utils.ExtractPage(deliveries, *pageSpec)
+	pageDeliveriesObjs := make([]response_models.WebhookDelivery, len(pageDeliveries))
+	for i, pageDelivery := range pageDeliveries {
+		pageDeliveriesObjs[i] = response_models.WebhookDelivery{
+			Id:            pageDelivery.Id,
+			EventType:     pageDelivery.EventType,
+			Payload:       pageDelivery.Payload,
+			Status:        pageDelivery.Status,
+			Attempts:      pageDelivery.Attempts,
+			ResponseCode:  pageDelivery.ResponseCode,
+			Error:         pageDelivery.Error,
+			NextAttemptOn: pageDelivery.NextAttemptOn.UTC().Format(time.RFC3339),
+			CreatedOn:     pageDelivery.CreatedOn.UTC().Format(time.RFC3339),
+			UpdatedOn:     pageDelivery.UpdatedOn.UTC().Format(time.RFC3339),
+		}
+	}
+	c.JSON(
+		200,
+		gin.H{
+			"success": true,
+			"data":    pageDeliveriesObjs,
+		},
+	)
+}
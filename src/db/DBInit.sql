@@ -11,10 +11,31 @@ CREATE TABLE IF NOT EXISTS users(
     sign_in_attempts INT NOT NULL DEFAULT 0 CHECK(sign_in_attempts >= 0),
     is_active BOOLEAN NOT NULL DEFAULT TRUE,
     account_reset_token TEXT NOT NULL DEFAULT '',
+    handle TEXT NOT NULL DEFAULT '',
+    digest_frequency TEXT NOT NULL DEFAULT 'none'
+        CHECK(digest_frequency IN ('none', 'daily', 'weekly')),
+    last_digest_on TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
     PRIMARY KEY (id),
     UNIQUE (email)
 );
 
+-- Gives users created before handles existed a handle derived from their name
+ALTER TABLE users ADD COLUMN IF NOT EXISTS handle TEXT NOT NULL DEFAULT '';
+UPDATE users SET handle=COALESCE(
+    NULLIF(left(lower(regexp_replace(name, '[^A-Za-z0-9_]', '', 'g')), 23), ''),
+    'user'
+) || '_' || left(md5(id), 6) WHERE handle='';
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_handle_idx
+    ON users (lower(handle));
+
+-- Adds the email digest preferences to users created before digests existed
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS digest_frequency TEXT NOT NULL DEFAULT 'none'
+        CHECK(digest_frequency IN ('none', 'daily', 'weekly')),
+    ADD COLUMN IF NOT EXISTS last_digest_on TIMESTAMP WITH TIME ZONE NOT NULL
+        DEFAULT CURRENT_TIMESTAMP;
+
 CREATE TABLE IF NOT EXISTS poems(
     id VARCHAR(36) NOT NULL DEFAULT '',
     created_on TIMESTAMP WITH TIME ZONE NOT NULL,
@@ -22,9 +43,33 @@ CREATE TABLE IF NOT EXISTS poems(
     user_id VARCHAR(36) NOT NULL REFERENCES users(id),
     title TEXT NOT NULL DEFAULT '' CHECK(length(title) <= 256),
     text TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'published'
+        CHECK(status IN ('draft', 'scheduled', 'published')),
+    publish_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    is_fanned_out BOOLEAN NOT NULL DEFAULT FALSE,
+    language REGCONFIG NOT NULL DEFAULT 'english',
     PRIMARY KEY (id)
 );
 
+-- Adds the publishing columns to poems created before drafts existed
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name='poems' AND column_name='publish_at'
+    ) THEN
+        ALTER TABLE poems
+            ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+                CHECK(status IN ('draft', 'scheduled', 'published')),
+            ADD COLUMN publish_at TIMESTAMP WITH TIME ZONE NOT NULL
+                DEFAULT CURRENT_TIMESTAMP;
+        UPDATE poems SET publish_at=created_on;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS poems_status_publish_at_idx
+    ON poems (status, publish_at);
+
 CREATE TABLE IF NOT EXISTS comments(
     id VARCHAR(36) NOT NULL DEFAULT '',
     user_id VARCHAR(36) NOT NULL REFERENCES users(id),
@@ -32,9 +77,64 @@ CREATE TABLE IF NOT EXISTS comments(
     comment_id VARCHAR(36) NOT NULL DEFAULT '',
     text TEXT NOT NULL CHECK(length(text) <= 384),
     created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    verse_index INT NOT NULL DEFAULT -1,
+    range_start INT NOT NULL DEFAULT -1,
+    range_end INT NOT NULL DEFAULT -1,
+    anchor_text TEXT NOT NULL DEFAULT '',
+    is_orphaned BOOLEAN NOT NULL DEFAULT FALSE,
+    depth INT NOT NULL DEFAULT 0,
+    is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (id)
+);
+
+-- Adds the verse anchors of annotations to comments made before them
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS verse_index INT NOT NULL DEFAULT -1,
+    ADD COLUMN IF NOT EXISTS range_start INT NOT NULL DEFAULT -1,
+    ADD COLUMN IF NOT EXISTS range_end INT NOT NULL DEFAULT -1,
+    ADD COLUMN IF NOT EXISTS anchor_text TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS is_orphaned BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS comments_poem_verse_idx
+    ON comments (poem_id, verse_index);
+
+-- Adds the thread depths and tombstones of comments made before them
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS depth INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS is_deleted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Top-level comments used to be saved as replies to themselves
+UPDATE comments SET comment_id='' WHERE comment_id=id;
+
+CREATE INDEX IF NOT EXISTS comments_comment_id_idx
+    ON comments (comment_id);
+
+-- Comments made before editing was possible were last updated when created
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS updated_on TIMESTAMP WITH TIME ZONE;
+UPDATE comments SET updated_on=created_on WHERE updated_on IS NULL;
+ALTER TABLE comments ALTER COLUMN updated_on SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS comment_revisions(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    comment_id VARCHAR(36) NOT NULL REFERENCES comments(id),
+    text TEXT NOT NULL,
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
     PRIMARY KEY (id)
 );
 
+CREATE INDEX IF NOT EXISTS comment_revisions_comment_idx
+    ON comment_revisions (comment_id, created_on);
+
+CREATE TABLE IF NOT EXISTS comments_likes(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    comment_id VARCHAR(36) NOT NULL REFERENCES comments(id),
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (user_id, comment_id)
+);
+
 CREATE TABLE IF NOT EXISTS users_followings(
     id VARCHAR(36) NOT NULL DEFAULT '',
     follower_id VARCHAR(36) NOT NULL REFERENCES users(id),
@@ -48,11 +148,19 @@ CREATE TABLE IF NOT EXISTS poems_likes(
     id VARCHAR(36) NOT NULL DEFAULT '',
     user_id VARCHAR(36) NOT NULL REFERENCES users(id),
     poem_id VARCHAR(36) NOT NULL REFERENCES poems(id),
+    reaction TEXT NOT NULL DEFAULT 'heart',
     created_on TIMESTAMP WITH TIME ZONE NOT NULL,
     PRIMARY KEY (id),
     UNIQUE (user_id, poem_id)
 );
 
+-- Existing likes become reactions of the default type
+ALTER TABLE poems_likes
+    ADD COLUMN IF NOT EXISTS reaction TEXT NOT NULL DEFAULT 'heart';
+
+CREATE INDEX IF NOT EXISTS poems_likes_poem_reaction_idx
+    ON poems_likes (poem_id, reaction);
+
 CREATE INDEX IF NOT EXISTS users_txt_search_idx
     ON users
         USING GIN (to_tsvector('english', name || ' ' || bio));
@@ -60,3 +168,287 @@ CREATE INDEX IF NOT EXISTS users_txt_search_idx
 CREATE INDEX IF NOT EXISTS poems_txt_search_idx
     ON poems
         USING GIN (to_tsvector('english', title || ' ' || text));
+
+CREATE TABLE IF NOT EXISTS poem_revisions(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    poem_id VARCHAR(36) NOT NULL REFERENCES poems(id),
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    title TEXT NOT NULL DEFAULT '' CHECK(length(title) <= 256),
+    text TEXT NOT NULL,
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS poem_revisions_poem_idx
+    ON poem_revisions (poem_id, created_on);
+
+CREATE TABLE IF NOT EXISTS tags(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    name TEXT NOT NULL CHECK(length(name) BETWEEN 1 AND 64),
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS poems_tags(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    poem_id VARCHAR(36) NOT NULL REFERENCES poems(id),
+    tag_id VARCHAR(36) NOT NULL REFERENCES tags(id),
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (poem_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS tags_name_prefix_idx
+    ON tags (name text_pattern_ops);
+
+CREATE INDEX IF NOT EXISTS poems_tags_tag_idx
+    ON poems_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS collections(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    title TEXT NOT NULL CHECK(length(title) BETWEEN 1 AND 128),
+    description TEXT NOT NULL DEFAULT '' CHECK(length(description) <= 384),
+    visibility TEXT NOT NULL DEFAULT 'public'
+        CHECK(visibility IN ('public', 'private')),
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS collections_poems(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    collection_id VARCHAR(36) NOT NULL REFERENCES collections(id),
+    poem_id VARCHAR(36) NOT NULL REFERENCES poems(id),
+    position INT NOT NULL CHECK(position >= 0),
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (collection_id, poem_id)
+);
+
+CREATE INDEX IF NOT EXISTS collections_user_idx
+    ON collections (user_id);
+
+CREATE INDEX IF NOT EXISTS collections_poems_position_idx
+    ON collections_poems (collection_id, position);
+
+CREATE TABLE IF NOT EXISTS bookmarks(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    poem_id VARCHAR(36) NOT NULL REFERENCES poems(id),
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (user_id, poem_id)
+);
+
+CREATE TABLE IF NOT EXISTS mentions(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    author_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    poem_id VARCHAR(36) NOT NULL REFERENCES poems(id),
+    comment_id VARCHAR(36) NOT NULL DEFAULT '',
+    verse_index INT NOT NULL DEFAULT -1,
+    "offset" INT NOT NULL CHECK("offset" >= 0),
+    length INT NOT NULL CHECK(length > 0),
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS mentions_user_idx
+    ON mentions (user_id, created_on);
+
+CREATE INDEX IF NOT EXISTS mentions_source_idx
+    ON mentions (poem_id, comment_id);
+
+CREATE TABLE IF NOT EXISTS handle_changes(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    old_handle TEXT NOT NULL,
+    new_handle TEXT NOT NULL,
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS handle_changes_old_handle_idx
+    ON handle_changes (lower(old_handle), created_on);
+
+CREATE TABLE IF NOT EXISTS notifications(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    actor_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    type TEXT NOT NULL
+        CHECK(type IN ('reaction', 'comment', 'reply', 'follow', 'mention')),
+    poem_id VARCHAR(36) NOT NULL DEFAULT '',
+    comment_id VARCHAR(36) NOT NULL DEFAULT '',
+    group_key TEXT NOT NULL,
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_idx
+    ON notifications (user_id, is_read, created_on);
+
+CREATE TABLE IF NOT EXISTS webhooks(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT NOT NULL,
+    scope TEXT NOT NULL DEFAULT 'user' CHECK(scope IN ('user', 'all')),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_user_idx
+    ON webhooks (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    webhook_id VARCHAR(36) NOT NULL REFERENCES webhooks(id),
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK(status IN ('pending', 'succeeded', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    response_code INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_queue_idx
+    ON webhook_deliveries (status, next_attempt_on);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx
+    ON webhook_deliveries (webhook_id, created_on);
+
+CREATE TABLE IF NOT EXISTS explore_rankings(
+    poem_id VARCHAR(36) NOT NULL REFERENCES poems(id),
+    score DOUBLE PRECISION NOT NULL,
+    ranked_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (poem_id)
+);
+
+CREATE INDEX IF NOT EXISTS explore_rankings_score_idx
+    ON explore_rankings (score DESC, poem_id);
+
+-- poems published before timelines existed are added to them below
+ALTER TABLE poems ADD COLUMN IF NOT EXISTS is_fanned_out BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE poems ALTER COLUMN is_fanned_out SET DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS poems_fan_out_on_read_idx
+    ON poems (user_id, publish_at DESC, id DESC)
+    WHERE status='published' AND is_fanned_out=FALSE;
+
+CREATE TABLE IF NOT EXISTS timelines(
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    poem_id VARCHAR(36) NOT NULL REFERENCES poems(id),
+    author_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    publish_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, poem_id)
+);
+
+CREATE INDEX IF NOT EXISTS timelines_feed_idx
+    ON timelines (user_id, publish_at DESC, poem_id DESC);
+
+CREATE INDEX IF NOT EXISTS timelines_author_idx
+    ON timelines (user_id, author_id);
+
+-- fill the timelines with the poems published before they existed
+INSERT INTO timelines (user_id, poem_id, author_id, publish_at)
+SELECT users_followings.follower_id, poems.id, poems.user_id, poems.publish_at
+FROM poems
+INNER JOIN users_followings ON users_followings.following_id=poems.user_id
+WHERE poems.status='published' AND NOT EXISTS (
+    SELECT 1 FROM timelines WHERE timelines.poem_id=poems.id
+)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO timelines (user_id, poem_id, author_id, publish_at)
+SELECT poems.user_id, poems.id, poems.user_id, poems.publish_at
+FROM poems
+WHERE poems.status='published' AND NOT EXISTS (
+    SELECT 1 FROM timelines WHERE timelines.poem_id=poems.id
+);
+
+CREATE TABLE IF NOT EXISTS users_blocks(
+    id VARCHAR(36) NOT NULL DEFAULT '',
+    blocker_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    blocked_id VARCHAR(36) NOT NULL REFERENCES users(id),
+    created_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (blocker_id, blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS users_blocks_blocked_idx
+    ON users_blocks (blocked_id);
+
+CREATE INDEX IF NOT EXISTS users_followings_following_idx
+    ON users_followings (following_id);
+
+CREATE INDEX IF NOT EXISTS poems_likes_user_idx
+    ON poems_likes (user_id, poem_id);
+
+-- search vectors weigh titles and names over verses and bios
+ALTER TABLE poems ADD COLUMN IF NOT EXISTS language REGCONFIG NOT NULL DEFAULT 'english';
+
+DROP INDEX IF EXISTS users_txt_search_idx;
+
+DROP INDEX IF EXISTS poems_txt_search_idx;
+
+CREATE INDEX IF NOT EXISTS users_weighted_txt_search_idx
+    ON users
+        USING GIN ((
+            setweight(to_tsvector('english', name), 'A') ||
+            setweight(to_tsvector('english', bio), 'B')
+        ));
+
+CREATE INDEX IF NOT EXISTS poems_weighted_txt_search_idx
+    ON poems
+        USING GIN ((
+            setweight(to_tsvector(language, title), 'A') ||
+            setweight(to_tsvector(language, text), 'B')
+        ));
+
+-- trigram indexes for fuzzy and prefix matching of names, handles and titles
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS users_name_trgm_idx
+    ON users
+        USING GIN (lower(name) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS users_handle_trgm_idx
+    ON users
+        USING GIN (lower(handle) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS poems_title_trgm_idx
+    ON poems
+        USING GIN (lower(title) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS comments_txt_search_idx
+    ON comments
+        USING GIN (to_tsvector('english', text));
+
+CREATE INDEX IF NOT EXISTS tags_name_trgm_idx
+    ON tags
+        USING GIN (name gin_trgm_ops);
+
+-- poems' text is a structured body of stanzas whose verses alone are searched
+DROP INDEX IF EXISTS poems_weighted_txt_search_idx;
+
+CREATE INDEX IF NOT EXISTS poems_body_txt_search_idx
+    ON poems
+        USING GIN ((
+            setweight(to_tsvector(language, title), 'A') ||
+            setweight(jsonb_to_tsvector(language, text::jsonb, '["string"]'), 'B')
+        ));
+
+-- The tombstones of deleted users' comments stay in threads with replies
+-- from other users, without being tied to their authors
+ALTER TABLE comments ALTER COLUMN user_id DROP NOT NULL;
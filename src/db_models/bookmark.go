@@ -0,0 +1,13 @@
+package db_models
+
+import "time"
+
+// Represents a private bookmark of a poem.
+type Bookmark struct {
+	Id        string    `db:"id"`
+	UserId    string    `db:"user_id"`
+	PoemId    string    `db:"poem_id"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t Bookmark) GetId() string { return t.Id }
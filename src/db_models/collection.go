@@ -0,0 +1,23 @@
+package db_models
+
+import "time"
+
+const (
+	// The visibility of a collection anyone can view.
+	CollectionVisibilityPublic = "public"
+	// The visibility of a collection only its owner can view.
+	CollectionVisibilityPrivate = "private"
+)
+
+// Represents a named collection of poems curated by a user.
+type Collection struct {
+	Id          string    `db:"id"`
+	UserId      string    `db:"user_id"`
+	Title       string    `db:"title"`
+	Description string    `db:"description"`
+	Visibility  string    `db:"visibility"`
+	CreatedOn   time.Time `db:"created_on"`
+	UpdatedOn   time.Time `db:"updated_on"`
+}
+
+func (t Collection) GetId() string { return t.Id }
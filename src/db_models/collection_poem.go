@@ -0,0 +1,14 @@
+package db_models
+
+import "time"
+
+// Represents a poem in a collection.
+type CollectionPoem struct {
+	Id           string    `db:"id"`
+	CollectionId string    `db:"collection_id"`
+	PoemId       string    `db:"poem_id"`
+	Position     int       `db:"position"`
+	CreatedOn    time.Time `db:"created_on"`
+}
+
+func (t CollectionPoem) GetId() string { return t.Id }
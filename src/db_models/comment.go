@@ -1,15 +1,29 @@
 package db_models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 // Represents a comment on a poem or reply to a comment.
+// Comments with a VerseIndex of -1 are made on the whole poem.
+// A deleted comment with replies is kept as a tombstone in its thread,
+// and has no UserId once its author has been deleted.
 type Comment struct {
-	Id        string    `db:"id"`
-	UserId    string    `db:"user_id"`
-	PoemId    string    `db:"poem_id"`
-	CommentId string    `db:"comment_id"`
-	Text      string    `db:"text"`
-	CreatedOn time.Time `db:"created_on"`
+	Id         string         `db:"id"`
+	UserId     sql.NullString `db:"user_id"`
+	PoemId     string         `db:"poem_id"`
+	CommentId  string         `db:"comment_id"`
+	Text       string         `db:"text"`
+	CreatedOn  time.Time      `db:"created_on"`
+	UpdatedOn  time.Time      `db:"updated_on"`
+	VerseIndex int            `db:"verse_index"`
+	RangeStart int            `db:"range_start"`
+	RangeEnd   int            `db:"range_end"`
+	AnchorText string         `db:"anchor_text"`
+	IsOrphaned bool           `db:"is_orphaned"`
+	Depth      int            `db:"depth"`
+	IsDeleted  bool           `db:"is_deleted"`
 }
 
 func (t Comment) GetId() string { return t.Id }
@@ -0,0 +1,13 @@
+package db_models
+
+import "time"
+
+// Represents a user's like on a comment.
+type CommentLike struct {
+	Id        string    `db:"id"`
+	UserId    string    `db:"user_id"`
+	CommentId string    `db:"comment_id"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t CommentLike) GetId() string { return t.Id }
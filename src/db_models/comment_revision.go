@@ -0,0 +1,13 @@
+package db_models
+
+import "time"
+
+// Represents a previous version of a comment.
+type CommentRevision struct {
+	Id        string    `db:"id"`
+	CommentId string    `db:"comment_id"`
+	Text      string    `db:"text"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t CommentRevision) GetId() string { return t.Id }
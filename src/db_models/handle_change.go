@@ -0,0 +1,14 @@
+package db_models
+
+import "time"
+
+// Represents a change of a user's handle.
+type HandleChange struct {
+	Id        string    `db:"id"`
+	UserId    string    `db:"user_id"`
+	OldHandle string    `db:"old_handle"`
+	NewHandle string    `db:"new_handle"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t HandleChange) GetId() string { return t.Id }
@@ -0,0 +1,20 @@
+package db_models
+
+import "time"
+
+// Represents a mention of a user in a poem's verse or in a comment.
+// Mentions in a poem's verses have an empty CommentId while mentions
+// in a comment have a VerseIndex of -1.
+type Mention struct {
+	Id         string    `db:"id"`
+	UserId     string    `db:"user_id"`
+	AuthorId   string    `db:"author_id"`
+	PoemId     string    `db:"poem_id"`
+	CommentId  string    `db:"comment_id"`
+	VerseIndex int       `db:"verse_index"`
+	Offset     int       `db:"offset"`
+	Length     int       `db:"length"`
+	CreatedOn  time.Time `db:"created_on"`
+}
+
+func (t Mention) GetId() string { return t.Id }
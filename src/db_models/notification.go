@@ -0,0 +1,27 @@
+package db_models
+
+import "time"
+
+const (
+	NotificationTypeReaction = "reaction"
+	NotificationTypeComment  = "comment"
+	NotificationTypeReply    = "reply"
+	NotificationTypeFollow   = "follow"
+	NotificationTypeMention  = "mention"
+)
+
+// Represents an event a user is notified about.
+// Notifications with the same GroupKey are shown together.
+type Notification struct {
+	Id        string    `db:"id"`
+	UserId    string    `db:"user_id"`
+	ActorId   string    `db:"actor_id"`
+	Type      string    `db:"type"`
+	PoemId    string    `db:"poem_id"`
+	CommentId string    `db:"comment_id"`
+	GroupKey  string    `db:"group_key"`
+	IsRead    bool      `db:"is_read"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t Notification) GetId() string { return t.Id }
@@ -2,14 +2,27 @@ package db_models
 
 import "time"
 
+const (
+	// The status of a poem that is only visible to its author.
+	PoemStatusDraft = "draft"
+	// The status of a poem waiting to be published at its PublishAt time.
+	PoemStatusScheduled = "scheduled"
+	// The status of a poem that is visible to everyone.
+	PoemStatusPublished = "published"
+)
+
 // Represents a poem.
 type Poem struct {
-	Id        string    `db:"id"`
-	CreatedOn time.Time `db:"created_on"`
-	UpdatedOn time.Time `db:"updated_on"`
-	UserId    string    `db:"user_id"`
-	Title     string    `db:"title"`
-	Text      string    `db:"text"`
+	Id          string    `db:"id"`
+	CreatedOn   time.Time `db:"created_on"`
+	UpdatedOn   time.Time `db:"updated_on"`
+	UserId      string    `db:"user_id"`
+	Title       string    `db:"title"`
+	Text        string    `db:"text"`
+	Status      string    `db:"status"`
+	PublishAt   time.Time `db:"publish_at"`
+	IsFannedOut bool      `db:"is_fanned_out"`
+	Language    string    `db:"language"`
 }
 
 func (t Poem) GetId() string { return t.Id }
@@ -2,11 +2,12 @@ package db_models
 
 import "time"
 
-// Represents a like on a poem.
+// Represents a user's reaction on a poem.
 type PoemLike struct {
 	Id        string    `db:"id"`
 	UserId    string    `db:"user_id"`
 	PoemId    string    `db:"poem_id"`
+	Reaction  string    `db:"reaction"`
 	CreatedOn time.Time `db:"created_on"`
 }
 
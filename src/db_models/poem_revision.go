@@ -0,0 +1,15 @@
+package db_models
+
+import "time"
+
+// Represents a previous version of a poem.
+type PoemRevision struct {
+	Id        string    `db:"id"`
+	PoemId    string    `db:"poem_id"`
+	UserId    string    `db:"user_id"`
+	Title     string    `db:"title"`
+	Text      string    `db:"text"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t PoemRevision) GetId() string { return t.Id }
@@ -0,0 +1,13 @@
+package db_models
+
+import "time"
+
+// Represents a tag attached to a poem.
+type PoemTag struct {
+	Id        string    `db:"id"`
+	PoemId    string    `db:"poem_id"`
+	TagId     string    `db:"tag_id"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t PoemTag) GetId() string { return t.Id }
@@ -0,0 +1,12 @@
+package db_models
+
+import "time"
+
+// Represents a tag that can be attached to poems.
+type Tag struct {
+	Id        string    `db:"id"`
+	Name      string    `db:"name"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t Tag) GetId() string { return t.Id }
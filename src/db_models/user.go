@@ -2,6 +2,12 @@ package db_models
 
 import "time"
 
+const (
+	DigestFrequencyNone   = "none"
+	DigestFrequencyDaily  = "daily"
+	DigestFrequencyWeekly = "weekly"
+)
+
 // Represents a user.
 type User struct {
 	Id                string    `db:"id"`
@@ -15,6 +21,9 @@ type User struct {
 	SignInAttempts    int       `db:"sign_in_attempts"`
 	IsActive          bool      `db:"is_active"`
 	AccountResetToken string    `db:"account_reset_token"`
+	Handle            string    `db:"handle"`
+	DigestFrequency   string    `db:"digest_frequency"`
+	LastDigestOn      time.Time `db:"last_digest_on"`
 }
 
 func (t User) GetId() string { return t.Id }
@@ -0,0 +1,13 @@
+package db_models
+
+import "time"
+
+// Represents a user hiding another user and preventing them from following them.
+type UserBlock struct {
+	Id        string    `db:"id"`
+	BlockerId string    `db:"blocker_id"`
+	BlockedId string    `db:"blocked_id"`
+	CreatedOn time.Time `db:"created_on"`
+}
+
+func (t UserBlock) GetId() string { return t.Id }
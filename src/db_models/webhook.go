@@ -0,0 +1,25 @@
+package db_models
+
+import "time"
+
+const (
+	WebhookScopeUser = "user"
+	WebhookScopeAll  = "all"
+)
+
+// Represents an endpoint that receives the events it's subscribed to.
+// Webhooks with the user scope only receive events about their owner,
+// while webhooks with the all scope can only be registered by admins.
+type Webhook struct {
+	Id         string    `db:"id"`
+	UserId     string    `db:"user_id"`
+	Url        string    `db:"url"`
+	Secret     string    `db:"secret"`
+	EventTypes string    `db:"event_types"`
+	Scope      string    `db:"scope"`
+	IsActive   bool      `db:"is_active"`
+	CreatedOn  time.Time `db:"created_on"`
+	UpdatedOn  time.Time `db:"updated_on"`
+}
+
+func (t Webhook) GetId() string { return t.Id }
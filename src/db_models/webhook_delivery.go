@@ -0,0 +1,26 @@
+package db_models
+
+import "time"
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+// Represents the delivery of an event to a webhook.
+type WebhookDelivery struct {
+	Id            string    `db:"id"`
+	WebhookId     string    `db:"webhook_id"`
+	EventType     string    `db:"event_type"`
+	Payload       string    `db:"payload"`
+	Status        string    `db:"status"`
+	Attempts      int       `db:"attempts"`
+	NextAttemptOn time.Time `db:"next_attempt_on"`
+	ResponseCode  int       `db:"response_code"`
+	Error         string    `db:"error"`
+	CreatedOn     time.Time `db:"created_on"`
+	UpdatedOn     time.Time `db:"updated_on"`
+}
+
+func (t WebhookDelivery) GetId() string { return t.Id }
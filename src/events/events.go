@@ -0,0 +1,138 @@
+package events
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// The Postgres channel events are sent through between server instances.
+	EVENTS_CHANNEL = "cartedepoezii_events"
+	// The number of events kept for a subscriber that's slow to receive them.
+	SUBSCRIPTION_BUFFER_SIZE = 32
+)
+
+const (
+	EventPoemCreated         = "poem.created"
+	EventPoemUpdated         = "poem.updated"
+	EventCommentCreated      = "comment.created"
+	EventUserFollowed        = "user.followed"
+	EventNotificationCreated = "notification.created"
+)
+
+// Represents something that happened which clients may be interested in.
+// Events only carry ids so they fit in a Postgres notification's payload.
+type Event struct {
+	Type           string `json:"type"`
+	UserId         string `json:"userId"`
+	AuthorId       string `json:"authorId"`
+	PoemId         string `json:"poemId"`
+	CommentId      string `json:"commentId"`
+	NotificationId string `json:"notificationId"`
+}
+
+// Represents a receiver of the events that pass its filter.
+type Subscription struct {
+	Events chan Event
+	filter func(Event) bool
+}
+
+var (
+	subscriptionsLock sync.Mutex
+	subscriptions     = make(map[*Subscription]bool)
+)
+
+// Sends an event to all server instances and queues its deliveries
+// to webhooks once the transaction is committed.
+func Publish(tx *sql.Tx, event Event) error {
+	err := enqueueWebhookDeliveries(tx, event)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("SELECT pg_notify($1, $2);", EVENTS_CHANNEL, string(payload))
+	return err
+}
+
+// Creates a subscription to the events that pass the given filter.
+func Subscribe(filter func(Event) bool) *Subscription {
+	subscription := &Subscription{
+		Events: make(chan Event, SUBSCRIPTION_BUFFER_SIZE),
+		filter: filter,
+	}
+	subscriptionsLock.Lock()
+	defer subscriptionsLock.Unlock()
+	subscriptions[subscription] = true
+	return subscription
+}
+
+// Removes a subscription.
+func Unsubscribe(subscription *Subscription) {
+	subscriptionsLock.Lock()
+	defer subscriptionsLock.Unlock()
+	delete(subscriptions, subscription)
+}
+
+// Passes an event to the subscriptions interested in it.
+// Events are dropped for subscriptions whose buffers are full.
+func dispatch(event Event) {
+	subscriptionsLock.Lock()
+	defer subscriptionsLock.Unlock()
+	for subscription := range subscriptions {
+		if !subscription.filter(event) {
+			continue
+		}
+		select {
+		case subscription.Events <- event:
+		default:
+		}
+	}
+}
+
+// Starts a background worker that listens for events from all
+// server instances and passes them to this instance's subscriptions.
+func StartListener() {
+	listener := pq.NewListener(
+		os.Getenv("DB_URL"),
+		10*time.Second,
+		time.Minute,
+		func(_ pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Println("events listener:", err)
+			}
+		},
+	)
+	err := listener.Listen(EVENTS_CHANNEL)
+	if err != nil {
+		log.Println("events listener:", err)
+	}
+	go func() {
+		for {
+			select {
+			case notification := <-listener.Notify:
+				// a nil notification is received after reconnecting
+				if notification == nil {
+					continue
+				}
+				event := Event{}
+				err := json.Unmarshal([]byte(notification.Extra), &event)
+				if err != nil {
+					log.Println("events listener:", err)
+					continue
+				}
+				dispatch(event)
+			case <-time.After(90 * time.Second):
+				go listener.Ping()
+			}
+		}
+	}()
+}
@@ -0,0 +1,169 @@
+package events
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/google/uuid"
+)
+
+// The event types webhooks can subscribe to.
+var WebhookEventTypes = []string{
+	EventPoemCreated,
+	EventPoemUpdated,
+	EventCommentCreated,
+	EventUserFollowed,
+}
+
+// Represents the body of a webhook delivery.
+type WebhookPayload struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	CreatedOn string `json:"createdOn"`
+	Data      any    `json:"data"`
+}
+
+// Checks if webhooks can subscribe to an event type.
+func IsWebhookEventType(eventType string) bool {
+	for _, webhookEventType := range WebhookEventTypes {
+		if webhookEventType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Retrieves the data sent to webhooks about an event.
+func getWebhookEventData(tx *sql.Tx, event Event) (any, error) {
+	switch event.Type {
+	case EventPoemCreated, EventPoemUpdated:
+		var id, userId, title, text string
+		var createdOn, updatedOn time.Time
+		err := tx.QueryRow(
+			"SELECT id, user_id, title, text, created_on, updated_on FROM poems WHERE id=$1;",
+			event.PoemId,
+		).Scan(&id, &userId, &title, &text, &createdOn, &updatedOn)
+		if err != nil {
+			return nil, err
+		}
+		body, err := utils.ParsePoemBody(text)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"id":        id,
+			"userId":    userId,
+			"title":     title,
+			"verses":    body.Verses(),
+			"body":      body,
+			"createdOn": createdOn.UTC().Format(time.RFC3339),
+			"updatedOn": updatedOn.UTC().Format(time.RFC3339),
+		}, nil
+	case EventCommentCreated:
+		var id, poemId, replyTo, text string
+		var userId sql.NullString
+		var createdOn time.Time
+		err := tx.QueryRow(
+			"SELECT id, user_id, poem_id, comment_id, text, created_on FROM comments WHERE id=$1;",
+			event.CommentId,
+		).Scan(&id, &userId, &poemId, &replyTo, &text, &createdOn)
+		if err != nil {
+			return nil, err
+		}
+		// the comment's author may have been deleted since
+		var author any
+		if userId.Valid {
+			author = userId.String
+		}
+		return map[string]any{
+			"id":        id,
+			"userId":    author,
+			"poemId":    poemId,
+			"replyTo":   replyTo,
+			"text":      text,
+			"createdOn": createdOn.UTC().Format(time.RFC3339),
+		}, nil
+	case EventUserFollowed:
+		return map[string]any{
+			"followerId":  event.AuthorId,
+			"followingId": event.UserId,
+		}, nil
+	}
+	return nil, nil
+}
+
+// Queues the deliveries of an event to the active webhooks subscribed to it.
+// Webhooks with the user scope only receive events about their owner.
+func enqueueWebhookDeliveries(tx *sql.Tx, event Event) error {
+	if !IsWebhookEventType(event.Type) {
+		return nil
+	}
+	rows, err := tx.Query(
+		`SELECT id, event_types FROM webhooks
+		WHERE is_active=TRUE AND (scope='all' OR user_id=$1 OR user_id=$2);`,
+		event.AuthorId,
+		event.UserId,
+	)
+	if err != nil {
+		return err
+	}
+	webhooksIds := []string{}
+	for rows.Next() {
+		webhookId, eventTypesTxt := "", ""
+		err = rows.Scan(&webhookId, &eventTypesTxt)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		eventTypes := []string{}
+		err = json.Unmarshal([]byte(eventTypesTxt), &eventTypes)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		for _, eventType := range eventTypes {
+			if eventType == event.Type {
+				webhooksIds = append(webhooksIds, webhookId)
+				break
+			}
+		}
+	}
+	rows.Close()
+	if len(webhooksIds) == 0 {
+		return nil
+	}
+	data, err := getWebhookEventData(tx, event)
+	if err != nil {
+		return err
+	}
+	currentTime := time.Now().UTC()
+	for _, webhookId := range webhooksIds {
+		deliveryId := uuid.New().String()
+		payload, err := json.Marshal(WebhookPayload{
+			Id:        deliveryId,
+			Type:      event.Type,
+			CreatedOn: currentTime.Format(time.RFC3339),
+			Data:      data,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO webhook_deliveries (
+				id, webhook_id, event_type, payload, next_attempt_on, created_on, updated_on
+			)
+			VALUES ($1, $2, $3, $4, $5, $5, $5);`,
+			deliveryId,
+			webhookId,
+			event.Type,
+			string(payload),
+			currentTime,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
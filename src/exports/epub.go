@@ -0,0 +1,244 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+)
+
+const (
+	// The style sheet of an EPUB book's pages.
+	EPUB_STYLE_SHEET = `body { font-family: serif; margin: 5%; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+.author { font-style: italic; margin-top: 0; }
+.stanza { margin: 1.2em 0; }
+.verse { margin: 0; padding-left: 2em; text-indent: -2em; }
+`
+	// The container file pointing to an EPUB book's package document.
+	EPUB_CONTAINER = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+)
+
+// Renders a collection of poems as an EPUB 3 book with a title page,
+// a table of contents and a page for each poem.
+func renderEpub(anthology Anthology, modifiedOn time.Time) ([]byte, error) {
+	language := getLanguageTag(anthology.Poems)
+	files := []struct {
+		Name    string
+		Content string
+	}{
+		{"META-INF/container.xml", EPUB_CONTAINER},
+		{"OEBPS/style.css", EPUB_STYLE_SHEET},
+		{"OEBPS/content.opf", renderEpubPackage(anthology, language, modifiedOn)},
+		{"OEBPS/toc.ncx", renderEpubNcx(anthology)},
+		{"OEBPS/nav.xhtml", renderEpubNav(anthology, language)},
+		{"OEBPS/title.xhtml", renderEpubPage(anthology.Title, language, renderEpubTitle(anthology))},
+	}
+	for i, poem := range anthology.Poems {
+		files = append(files, struct {
+			Name    string
+			Content string
+		}{
+			"OEBPS/" + getEpubPoemFileName(i),
+			renderEpubPage(poem.Title, language, renderEpubPoem(poem)),
+		})
+	}
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+	// the mimetype must be the first file and stored uncompressed
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	_, err = writer.Write([]byte("application/epub+zip"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		writer, err = archive.CreateHeader(&zip.FileHeader{
+			Name:     file.Name,
+			Method:   zip.Deflate,
+			Modified: modifiedOn,
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = writer.Write([]byte(file.Content))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = archive.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Retrieves the name of the page of a book's poem.
+func getEpubPoemFileName(index int) string {
+	return fmt.Sprintf("poem-%d.xhtml", index+1)
+}
+
+// Renders the package document describing a book's metadata and files.
+func renderEpubPackage(anthology Anthology, language string, modifiedOn time.Time) string {
+	manifest := strings.Builder{}
+	spine := strings.Builder{}
+	for i := range anthology.Poems {
+		fmt.Fprintf(
+			&manifest,
+			"    <item id=\"poem-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n",
+			i+1,
+			getEpubPoemFileName(i),
+		)
+		fmt.Fprintf(&spine, "    <itemref idref=\"poem-%d\"/>\n", i+1)
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="` + language + `">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">urn:cartedepoezii:` + html.EscapeString(anthology.Id) + `</dc:identifier>
+    <dc:title>` + html.EscapeString(anthology.Title) + `</dc:title>
+    <dc:creator>` + html.EscapeString(anthology.Author) + `</dc:creator>
+    <dc:language>` + language + `</dc:language>
+    <meta property="dcterms:modified">` + modifiedOn.Format("2006-01-02T15:04:05Z") + `</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+    <item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>
+` + manifest.String() + `  </manifest>
+  <spine toc="ncx">
+    <itemref idref="title"/>
+    <itemref idref="nav"/>
+` + spine.String() + `  </spine>
+</package>
+`
+}
+
+// Renders the table of contents of a book for EPUB 2 readers.
+func renderEpubNcx(anthology Anthology) string {
+	points := strings.Builder{}
+	for i, poem := range anthology.Poems {
+		fmt.Fprintf(
+			&points,
+			"    <navPoint id=\"poem-%d\" playOrder=\"%d\">\n"+
+				"      <navLabel><text>%s</text></navLabel>\n"+
+				"      <content src=\"%s\"/>\n"+
+				"    </navPoint>\n",
+			i+1,
+			i+1,
+			html.EscapeString(poem.Title),
+			getEpubPoemFileName(i),
+		)
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="urn:cartedepoezii:` + html.EscapeString(anthology.Id) + `"/>
+  </head>
+  <docTitle><text>` + html.EscapeString(anthology.Title) + `</text></docTitle>
+  <navMap>
+` + points.String() + `  </navMap>
+</ncx>
+`
+}
+
+// Renders the table of contents page of a book.
+func renderEpubNav(anthology Anthology, language string) string {
+	items := strings.Builder{}
+	for i, poem := range anthology.Poems {
+		fmt.Fprintf(
+			&items,
+			"        <li><a href=\"%s\">%s</a></li>\n",
+			getEpubPoemFileName(i),
+			html.EscapeString(poem.Title),
+		)
+	}
+	return renderEpubPage("Contents", language, `    <nav epub:type="toc" id="toc">
+      <h1>Contents</h1>
+      <ol>
+`+items.String()+`      </ol>
+    </nav>
+`)
+}
+
+// Renders the contents of the title page of a book.
+func renderEpubTitle(anthology Anthology) string {
+	return `    <section epub:type="titlepage">
+      <h1>` + html.EscapeString(anthology.Title) + `</h1>
+      <p class="author">` + html.EscapeString(anthology.Author) + `</p>
+    </section>
+`
+}
+
+// Renders the contents of the page of a poem.
+func renderEpubPoem(poem Poem) string {
+	text := strings.Builder{}
+	text.WriteString("    <section epub:type=\"chapter\">\n")
+	text.WriteString("      <h1>" + html.EscapeString(poem.Title) + "</h1>\n")
+	if len(poem.Author) > 0 {
+		text.WriteString("      <p class=\"author\">by " + html.EscapeString(poem.Author) + "</p>\n")
+	}
+	for _, stanza := range poem.Body.Stanzas {
+		text.WriteString("      <div class=\"stanza\">\n")
+		for _, verse := range stanza.Verses {
+			fmt.Fprintf(
+				&text,
+				"        <p class=\"verse\" style=\"margin-left: %dem;\">%s</p>\n",
+				verse.Indent*2,
+				renderEpubVerse(verse.Text),
+			)
+		}
+		text.WriteString("      </div>\n")
+	}
+	text.WriteString("    </section>\n")
+	return text.String()
+}
+
+// Renders the text of a verse with its italic and bold runs.
+func renderEpubVerse(verse string) string {
+	spans, err := utils.ParseInlineMarkup(verse)
+	if err != nil {
+		return html.EscapeString(verse)
+	}
+	text := strings.Builder{}
+	for _, span := range spans {
+		run := html.EscapeString(span.Text)
+		if span.IsBold {
+			run = "<strong>" + run + "</strong>"
+		}
+		if span.IsItalic {
+			run = "<em>" + run + "</em>"
+		}
+		text.WriteString(run)
+	}
+	return text.String()
+}
+
+// Renders an XHTML page of a book.
+func renderEpubPage(title, language, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="` +
+		language + `" xml:lang="` + language + `">
+  <head>
+    <meta charset="UTF-8"/>
+    <title>` + html.EscapeString(title) + `</title>
+    <link rel="stylesheet" type="text/css" href="style.css"/>
+  </head>
+  <body>
+` + body + `  </body>
+</html>
+`
+}
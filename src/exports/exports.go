@@ -0,0 +1,132 @@
+package exports
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+)
+
+const (
+	FORMAT_MARKDOWN = "markdown"
+	FORMAT_TEXT     = "text"
+	FORMAT_EPUB     = "epub"
+	FORMAT_PDF      = "pdf"
+)
+
+var (
+	// The language tags of the text search configurations poems are written in.
+	languageTags = map[string]string{
+		"arabic": "ar", "danish": "da", "dutch": "nl", "english": "en",
+		"finnish": "fi", "french": "fr", "german": "de", "hungarian": "hu",
+		"italian": "it", "norwegian": "no", "portuguese": "pt", "romanian": "ro",
+		"russian": "ru", "spanish": "es", "swedish": "sv", "turkish": "tr",
+	}
+)
+
+// Represents a poem as it is exported.
+type Poem struct {
+	Id          string
+	Title       string
+	Author      string
+	Language    string
+	PublishedOn time.Time
+	Body        *utils.PoemBody
+}
+
+// Represents a collection of poems exported as a book.
+type Anthology struct {
+	Id     string
+	Title  string
+	Author string
+	Poems  []Poem
+}
+
+// Represents an exported file.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Exports a poem as a Markdown, plain text, EPUB or PDF file.
+func ExportPoem(poem Poem, format string) (*File, error) {
+	name := getFileName(poem.Title, "poem")
+	switch format {
+	case FORMAT_MARKDOWN:
+		return &File{
+			Name:        name + ".md",
+			ContentType: "text/markdown; charset=utf-8",
+			Data:        []byte(renderMarkdown(poem)),
+		}, nil
+	case FORMAT_TEXT:
+		return &File{
+			Name:        name + ".txt",
+			ContentType: "text/plain; charset=utf-8",
+			Data:        []byte(renderText(poem)),
+		}, nil
+	}
+	return ExportAnthology(
+		Anthology{
+			Id:     poem.Id,
+			Title:  poem.Title,
+			Author: poem.Author,
+			Poems:  []Poem{poem},
+		},
+		format,
+	)
+}
+
+// Exports a collection of poems as an EPUB book with a table
+// of contents or as a PDF document.
+func ExportAnthology(anthology Anthology, format string) (*File, error) {
+	if len(anthology.Poems) < 1 {
+		return nil, errors.New("There are no poems to export.")
+	}
+	name := getFileName(anthology.Title, "poems")
+	switch format {
+	case FORMAT_EPUB:
+		data, err := renderEpub(anthology, time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		return &File{Name: name + ".epub", ContentType: "application/epub+zip", Data: data}, nil
+	case FORMAT_PDF:
+		data, err := renderPdf(anthology)
+		if err != nil {
+			return nil, err
+		}
+		return &File{Name: name + ".pdf", ContentType: "application/pdf", Data: data}, nil
+	}
+	return nil, errors.New("Unsupported export format.")
+}
+
+// Creates the name of an exported file from its title.
+func getFileName(title, fallback string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return fallback
+	}
+	return strings.Join(words, "-")
+}
+
+// Retrieves the language tag of the language most of a collection's
+// poems are written in, which is undetermined if it is unknown.
+func getLanguageTag(poems []Poem) string {
+	counts := make(map[string]int)
+	language := ""
+	for _, poem := range poems {
+		counts[poem.Language]++
+		if counts[poem.Language] > counts[language] {
+			language = poem.Language
+		}
+	}
+	if tag, ok := languageTags[language]; ok {
+		return tag
+	}
+	return "und"
+}
@@ -0,0 +1,342 @@
+package exports
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+)
+
+const (
+	// The width and height of an A4 page in points.
+	PDF_PAGE_WIDTH  = 595.0
+	PDF_PAGE_HEIGHT = 842.0
+	// The margin around a page's text in points.
+	PDF_MARGIN = 72.0
+	// The font sizes of titles, authors and verses.
+	PDF_TITLE_SIZE  = 18.0
+	PDF_AUTHOR_SIZE = 11.0
+	PDF_VERSE_SIZE  = 11.0
+	// The height of a line of verse and the gap between stanzas.
+	PDF_LINE_HEIGHT = 16.0
+	PDF_STANZA_GAP  = 10.0
+	// The width of a verse's indentation level, which wrapped
+	// verses are also indented by after their first line.
+	PDF_INDENT_WIDTH = 24.0
+)
+
+const (
+	PDF_FONT_REGULAR     = "F1"
+	PDF_FONT_ITALIC      = "F2"
+	PDF_FONT_BOLD        = "F3"
+	PDF_FONT_BOLD_ITALIC = "F4"
+)
+
+var (
+	// The standard fonts every PDF reader has, which need not be embedded.
+	pdfFonts = []struct {
+		Name     string
+		BaseFont string
+	}{
+		{PDF_FONT_REGULAR, "Helvetica"},
+		{PDF_FONT_ITALIC, "Helvetica-Oblique"},
+		{PDF_FONT_BOLD, "Helvetica-Bold"},
+		{PDF_FONT_BOLD_ITALIC, "Helvetica-BoldOblique"},
+	}
+	// The widths of Helvetica's printable ASCII characters in thousandths of
+	// the font size, which are used to approximate the widths of other fonts.
+	pdfCharWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	// The WinAnsi codes of the characters outside of Latin-1.
+	pdfWinAnsiCodes = map[rune]byte{
+		'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+		'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91,
+		'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+		'™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+	}
+	pdfTokenPattern = regexp.MustCompile(`\s+|\S+`)
+)
+
+// Represents a run of text drawn with the same font.
+type pdfRun struct {
+	Font string
+	Size float64
+	Text string
+}
+
+// Represents a PDF document being laid out page by page.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+// Renders a collection of poems as a PDF document with each poem
+// starting on a new page. The standard fonts only have Latin characters,
+// so poems with other characters can't be rendered.
+func renderPdf(anthology Anthology) ([]byte, error) {
+	err := checkPdfText(anthology.Title, anthology.Author)
+	if err != nil {
+		return nil, err
+	}
+	document := &pdfDocument{}
+	for _, poem := range anthology.Poems {
+		err = checkPdfText(poem.Title, poem.Author)
+		if err != nil {
+			return nil, err
+		}
+		for _, stanza := range poem.Body.Stanzas {
+			for _, verse := range stanza.Verses {
+				err = checkPdfText(verse.Text)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		document.addPage()
+		document.writeText(PDF_MARGIN, 0, PDF_TITLE_SIZE*1.4, []pdfRun{
+			{Font: PDF_FONT_BOLD, Size: PDF_TITLE_SIZE, Text: poem.Title},
+		})
+		if len(poem.Author) > 0 {
+			document.writeText(PDF_MARGIN, 0, PDF_LINE_HEIGHT, []pdfRun{
+				{Font: PDF_FONT_ITALIC, Size: PDF_AUTHOR_SIZE, Text: "by " + poem.Author},
+			})
+		}
+		for _, stanza := range poem.Body.Stanzas {
+			document.y -= PDF_STANZA_GAP
+			for _, verse := range stanza.Verses {
+				document.writeVerse(verse)
+			}
+		}
+	}
+	return document.encode(anthology)
+}
+
+// Starts a new page.
+func (document *pdfDocument) addPage() {
+	document.page = &bytes.Buffer{}
+	document.pages = append(document.pages, document.page)
+	document.y = PDF_PAGE_HEIGHT - PDF_MARGIN
+}
+
+// Writes a line of text runs below the previous line, starting
+// a new page if the line doesn't fit on the current one.
+func (document *pdfDocument) writeLine(x, height float64, runs []pdfRun) {
+	if document.y-height < PDF_MARGIN {
+		document.addPage()
+	}
+	document.y -= height
+	fmt.Fprintf(document.page, "BT\n%.2f %.2f Td\n", x, document.y)
+	for _, run := range runs {
+		fmt.Fprintf(document.page, "/%s %.1f Tf\n(%s) Tj\n", run.Font, run.Size, encodePdfText(run.Text))
+	}
+	document.page.WriteString("ET\n")
+}
+
+// Writes a verse with its indentation and formatting,
+// wrapping it on as many lines as it needs.
+func (document *pdfDocument) writeVerse(verse utils.PoemVerse) {
+	spans, err := utils.ParseInlineMarkup(verse.Text)
+	if err != nil {
+		spans = []utils.TextSpan{{Text: verse.Text}}
+	}
+	runs := make([]pdfRun, len(spans))
+	for i, span := range spans {
+		font := PDF_FONT_REGULAR
+		switch {
+		case span.IsBold && span.IsItalic:
+			font = PDF_FONT_BOLD_ITALIC
+		case span.IsBold:
+			font = PDF_FONT_BOLD
+		case span.IsItalic:
+			font = PDF_FONT_ITALIC
+		}
+		runs[i] = pdfRun{Font: font, Size: PDF_VERSE_SIZE, Text: span.Text}
+	}
+	x := PDF_MARGIN + float64(verse.Indent)*PDF_INDENT_WIDTH
+	document.writeText(x, PDF_INDENT_WIDTH, PDF_LINE_HEIGHT, runs)
+}
+
+// Writes text runs starting at a given position, wrapping them on as many
+// lines as they need with the lines after the first one further indented.
+func (document *pdfDocument) writeText(x, indent, height float64, runs []pdfRun) {
+	lineX, lineWidth := x, 0.0
+	line := []pdfRun{}
+	isWrapped := false
+	for _, run := range runs {
+		for _, token := range pdfTokenPattern.FindAllString(run.Text, -1) {
+			isSpace := strings.TrimSpace(token) == ""
+			width := getPdfTextWidth(token, run.Size)
+			if !isSpace && len(line) > 0 && lineX+lineWidth+width > PDF_PAGE_WIDTH-PDF_MARGIN {
+				document.writeLine(lineX, height, line)
+				lineX, lineWidth = x+indent, 0
+				line = []pdfRun{}
+				isWrapped = true
+			}
+			// wrapped lines don't start with the spaces they were wrapped at
+			if isSpace && len(line) == 0 && isWrapped {
+				continue
+			}
+			last := len(line) - 1
+			if last >= 0 && line[last].Font == run.Font && line[last].Size == run.Size {
+				line[last].Text += token
+			} else {
+				line = append(line, pdfRun{Font: run.Font, Size: run.Size, Text: token})
+			}
+			lineWidth += width
+		}
+	}
+	document.writeLine(lineX, height, line)
+}
+
+// Approximates the width of a text in points.
+func getPdfTextWidth(text string, size float64) float64 {
+	width := 0
+	for _, r := range text {
+		if r >= ' ' && r <= '~' {
+			width += pdfCharWidths[r-' ']
+		} else {
+			width += 556
+		}
+	}
+	// bold and oblique characters are slightly wider
+	return float64(width) * size / 1000 * 1.05
+}
+
+// Retrieves the code of a character in the WinAnsi encoding
+// of the standard fonts, if the encoding has it.
+func getPdfCharCode(r rune) (byte, bool) {
+	switch {
+	case r == '\t':
+		return ' ', true
+	case r >= ' ' && r <= '~', r >= 0xA0 && r <= 0xFF:
+		return byte(r), true
+	}
+	code, ok := pdfWinAnsiCodes[r]
+	return code, ok
+}
+
+// Checks if texts only have characters the standard fonts can render.
+func checkPdfText(texts ...string) error {
+	for _, text := range texts {
+		for _, r := range text {
+			if _, ok := getPdfCharCode(r); !ok {
+				return errors.New("PDF export only supports Latin text, export as EPUB instead.")
+			}
+		}
+	}
+	return nil
+}
+
+// Encodes a text as a PDF string in the WinAnsi encoding of the standard fonts.
+func encodePdfText(text string) string {
+	encoded := strings.Builder{}
+	for _, r := range text {
+		code, ok := getPdfCharCode(r)
+		switch {
+		case !ok:
+			encoded.WriteByte('?')
+		case code == '(' || code == ')' || code == '\\':
+			encoded.WriteByte('\\')
+			encoded.WriteByte(code)
+		case code >= ' ' && code <= '~':
+			encoded.WriteByte(code)
+		default:
+			fmt.Fprintf(&encoded, "\\%03o", code)
+		}
+	}
+	return encoded.String()
+}
+
+// Writes the laid out pages as a PDF file. Its objects are the catalog,
+// the page tree, the fonts, each page and its contents, then its metadata.
+func (document *pdfDocument) encode(anthology Anthology) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	offsets := []int{}
+	writeObject := func(body []byte) {
+		offsets = append(offsets, buffer.Len())
+		fmt.Fprintf(buffer, "%d 0 obj\n", len(offsets))
+		buffer.Write(body)
+		buffer.WriteString("\nendobj\n")
+	}
+	pagesStart := 3 + len(pdfFonts)
+	buffer.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	writeObject([]byte("<< /Type /Catalog /Pages 2 0 R >>"))
+	kids := make([]string, len(document.pages))
+	for i := range document.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pagesStart+2*i)
+	}
+	writeObject([]byte(fmt.Sprintf(
+		"<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "),
+		len(document.pages),
+	)))
+	fonts := []string{}
+	for i, font := range pdfFonts {
+		writeObject([]byte(fmt.Sprintf(
+			"<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>",
+			font.BaseFont,
+		)))
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", font.Name, 3+i))
+	}
+	for i, page := range document.pages {
+		writeObject([]byte(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+				"/Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			PDF_PAGE_WIDTH,
+			PDF_PAGE_HEIGHT,
+			strings.Join(fonts, " "),
+			pagesStart+2*i+1,
+		)))
+		// number the pages at their bottom
+		number := fmt.Sprintf("%d", i+1)
+		fmt.Fprintf(
+			page,
+			"BT\n/%s 9 Tf\n%.2f %.2f Td\n(%s) Tj\nET\n",
+			PDF_FONT_REGULAR,
+			(PDF_PAGE_WIDTH-getPdfTextWidth(number, 9))/2,
+			PDF_MARGIN/2,
+			number,
+		)
+		contents := &bytes.Buffer{}
+		writer := zlib.NewWriter(contents)
+		_, err := writer.Write(page.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		err = writer.Close()
+		if err != nil {
+			return nil, err
+		}
+		stream := fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n", contents.Len())
+		writeObject(append(append([]byte(stream), contents.Bytes()...), "\nendstream"...))
+	}
+	writeObject([]byte(fmt.Sprintf(
+		"<< /Title (%s) /Author (%s) /Producer (Cartedepoezii) >>",
+		encodePdfText(anthology.Title),
+		encodePdfText(anthology.Author),
+	)))
+	xrefOffset := buffer.Len()
+	fmt.Fprintf(buffer, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(
+		buffer,
+		"trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1,
+		len(offsets),
+		xrefOffset,
+	)
+	return buffer.Bytes(), nil
+}
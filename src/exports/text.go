@@ -0,0 +1,81 @@
+package exports
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+)
+
+var (
+	markdownEscaper = strings.NewReplacer(
+		"\\", "\\\\", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]",
+		"<", "\\<", ">", "\\>", "#", "\\#", "|", "\\|", "~", "\\~",
+	)
+	// a verse starting like a list item or a setext heading underline
+	markdownBlockPattern = regexp.MustCompile(`^(\d*)([-+=.)])`)
+)
+
+// Renders a poem as Markdown, with each verse on its own line and
+// stanzas as paragraphs. Indentation uses non-breaking spaces since
+// leading spaces would start code blocks.
+func renderMarkdown(poem Poem) string {
+	text := strings.Builder{}
+	text.WriteString("# " + markdownEscaper.Replace(poem.Title) + "\n\n")
+	if len(poem.Author) > 0 {
+		text.WriteString("*by " + markdownEscaper.Replace(poem.Author) + "*\n\n")
+	}
+	for i, stanza := range poem.Body.Stanzas {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		for j, verse := range stanza.Verses {
+			line := strings.Repeat("\u00a0", verse.Indent*utils.VerseIndentWidth)
+			spans, err := utils.ParseInlineMarkup(verse.Text)
+			if err != nil {
+				spans = []utils.TextSpan{{Text: verse.Text}}
+			}
+			for _, span := range spans {
+				line += renderMarkdownSpan(span)
+			}
+			if verse.Indent == 0 {
+				line = markdownBlockPattern.ReplaceAllString(line, `$1\$2`)
+			}
+			if j < len(stanza.Verses)-1 {
+				// a trailing backslash breaks the line without ending the paragraph
+				line += "\\"
+			}
+			text.WriteString(line + "\n")
+		}
+	}
+	return text.String()
+}
+
+// Renders a formatted run of a verse as Markdown. The spaces around
+// a run are kept outside its markers so that they are recognized.
+func renderMarkdownSpan(span utils.TextSpan) string {
+	marker := ""
+	if span.IsItalic {
+		marker += "*"
+	}
+	if span.IsBold {
+		marker += "**"
+	}
+	text := strings.TrimSpace(span.Text)
+	if len(marker) == 0 || len(text) == 0 {
+		return markdownEscaper.Replace(span.Text)
+	}
+	start := strings.Index(span.Text, text)
+	return span.Text[:start] +
+		marker + markdownEscaper.Replace(text) + marker +
+		span.Text[start+len(text):]
+}
+
+// Renders a poem as plain text.
+func renderText(poem Poem) string {
+	text := poem.Title + "\n"
+	if len(poem.Author) > 0 {
+		text += "by " + poem.Author + "\n"
+	}
+	return text + "\n" + poem.Body.PlainText() + "\n"
+}
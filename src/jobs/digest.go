@@ -0,0 +1,219 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// The interval between checks for users whose email digests are due.
+	DIGEST_MAILER_INTERVAL = time.Hour
+	// The maximum number of new poems listed in an email digest.
+	DIGEST_MAX_POEMS = 10
+	// The maximum number of users whose email digests are claimed at once.
+	DIGEST_BATCH_SIZE = 100
+)
+
+// Represents a user whose email digest has been claimed, with
+// the time of their digest before it was claimed.
+type digestUser struct {
+	db_models.User
+	PreviousDigestOn time.Time `db:"previous_digest_on"`
+}
+
+// Represents a poem listed in an email digest.
+type digestPoem struct {
+	Title      string `db:"title"`
+	AuthorName string `db:"author_name"`
+}
+
+// Creates the email digest of a user's activity since their last digest.
+// The second value is false if nothing happened in that period.
+func buildDigest(db *sqlx.DB, user *db_models.User, currentTime time.Time) (*utils.MailMessage, bool, error) {
+	poems := []digestPoem{}
+	err := db.Select(
+		&poems,
+		`SELECT poems.title, users.name AS author_name FROM poems
+		INNER JOIN users_followings ON users_followings.following_id=poems.user_id
+		INNER JOIN users ON users.id=poems.user_id
+		WHERE users_followings.follower_id=$1 AND poems.status='published'
+			AND poems.publish_at>$2 AND poems.publish_at<=$3
+		ORDER BY poems.publish_at DESC LIMIT $4;`,
+		user.Id,
+		user.LastDigestOn,
+		currentTime,
+		DIGEST_MAX_POEMS,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	reactionsCount := 0
+	err = db.Get(
+		&reactionsCount,
+		`SELECT COUNT(*) FROM poems_likes
+		INNER JOIN poems ON poems.id=poems_likes.poem_id
+		WHERE poems.user_id=$1 AND poems_likes.user_id<>$1
+			AND poems_likes.created_on>$2 AND poems_likes.created_on<=$3;`,
+		user.Id,
+		user.LastDigestOn,
+		currentTime,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	commentsCount := 0
+	err = db.Get(
+		&commentsCount,
+		`SELECT COUNT(*) FROM comments
+		INNER JOIN poems ON poems.id=comments.poem_id
+		WHERE poems.user_id=$1 AND comments.user_id<>$1 AND comments.is_deleted=FALSE
+			AND comments.created_on>$2 AND comments.created_on<=$3;`,
+		user.Id,
+		user.LastDigestOn,
+		currentTime,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	followersCount := 0
+	err = db.Get(
+		&followersCount,
+		`SELECT COUNT(*) FROM users_followings
+		WHERE following_id=$1 AND created_on>$2 AND created_on<=$3;`,
+		user.Id,
+		user.LastDigestOn,
+		currentTime,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(poems) == 0 && reactionsCount == 0 && commentsCount == 0 && followersCount == 0 {
+		return nil, false, nil
+	}
+	lines := []string{fmt.Sprintf("Hi %s,", user.Name), ""}
+	if len(poems) > 0 {
+		lines = append(lines, "New poems from poets you follow:")
+		for _, poem := range poems {
+			lines = append(lines, fmt.Sprintf("  - \"%s\" by %s", poem.Title, poem.AuthorName))
+		}
+		lines = append(lines, "")
+	}
+	if reactionsCount > 0 || commentsCount > 0 || followersCount > 0 {
+		lines = append(lines, "Activity on your poems:")
+		lines = append(lines, fmt.Sprintf("  - %d new reaction(s)", reactionsCount))
+		lines = append(lines, fmt.Sprintf("  - %d new comment(s)", commentsCount))
+		lines = append(lines, fmt.Sprintf("  - %d new follower(s)", followersCount))
+		lines = append(lines, "")
+	}
+	lines = append(lines, "You can change how often you get this email in your settings.")
+	subject := "Your daily Cartedepoezii digest"
+	if user.DigestFrequency == db_models.DigestFrequencyWeekly {
+		subject = "Your weekly Cartedepoezii digest"
+	}
+	return &utils.MailMessage{
+		To:      user.Email,
+		Subject: subject,
+		Body:    strings.Join(lines, "\n"),
+	}, true, nil
+}
+
+// Claims a batch of users whose email digests are due so other server
+// instances don't send them too, by moving their last digest to a given time.
+// Users whose digests failed to be sent in the current run are skipped.
+func claimDigestUsers(db *sqlx.DB, currentTime time.Time, failedIds []string) ([]digestUser, error) {
+	users := []digestUser{}
+	err := db.Select(
+		&users,
+		`UPDATE users SET last_digest_on=$1
+		FROM (
+			SELECT id, last_digest_on FROM users WHERE is_active=TRUE AND (
+				(digest_frequency='daily' AND last_digest_on<=$2) OR
+				(digest_frequency='weekly' AND last_digest_on<=$3)
+			) AND NOT (id=ANY($5))
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		) AS due
+		WHERE users.id=due.id
+		RETURNING users.*, due.last_digest_on AS previous_digest_on;`,
+		currentTime,
+		currentTime.Add(-24*time.Hour),
+		currentTime.Add(-7*24*time.Hour),
+		DIGEST_BATCH_SIZE,
+		pq.Array(failedIds),
+	)
+	return users, err
+}
+
+// Gives back a claimed user's email digest so that it is sent later.
+func releaseDigestUser(db *sqlx.DB, user *digestUser) error {
+	_, err := db.Exec(
+		"UPDATE users SET last_digest_on=$1 WHERE id=$2 AND last_digest_on=$3;",
+		user.PreviousDigestOn,
+		user.Id,
+		user.LastDigestOn,
+	)
+	return err
+}
+
+// Sends the email digests that are due and returns the number sent.
+func SendDigests(mailer utils.Mailer) (int, error) {
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	currentTime := time.Now().UTC()
+	sentCount := 0
+	failedIds := []string{}
+	for {
+		users, err := claimDigestUsers(db, currentTime, failedIds)
+		if err != nil || len(users) == 0 {
+			return sentCount, err
+		}
+		for i := range users {
+			claimedOn := users[i].LastDigestOn
+			// digests cover the activity since the previous one
+			users[i].LastDigestOn = users[i].PreviousDigestOn
+			message, hasActivity, err := buildDigest(db, &users[i].User, currentTime)
+			if err == nil && hasActivity {
+				err = mailer.Send(*message)
+			}
+			users[i].LastDigestOn = claimedOn
+			if err != nil {
+				log.Println("digest mailer:", err)
+				failedIds = append(failedIds, users[i].Id)
+				err = releaseDigestUser(db, &users[i])
+				if err != nil {
+					return sentCount, err
+				}
+				continue
+			}
+			if hasActivity {
+				sentCount++
+			}
+		}
+	}
+}
+
+// Starts a background worker that periodically sends the email digests that are due.
+func StartDigestMailer(interval time.Duration, mailer utils.Mailer) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			count, err := SendDigests(mailer)
+			if err != nil {
+				log.Println("digest mailer:", err)
+			} else if count > 0 {
+				log.Printf("digest mailer: sent %d digest(s)\n", count)
+			}
+		}
+	}()
+}
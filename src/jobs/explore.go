@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+)
+
+const (
+	// The interval between refreshes of the explore feed's rankings.
+	EXPLORE_RANKER_INTERVAL = 10 * time.Minute
+	// The maximum number of poems ranked for the explore feed.
+	EXPLORE_MAX_RANKED_POEMS = 1000
+	// The age in hours at which a poem's score is halved.
+	EXPLORE_HALF_LIFE_HOURS = 48.0
+	// The period in which likes and comments count towards a poem's velocity.
+	EXPLORE_VELOCITY_WINDOW = 24 * time.Hour
+	// The factor applied to the score of each subsequent poem by the same
+	// author, so that a few prolific authors don't fill the feed.
+	EXPLORE_AUTHOR_DIVERSITY_DECAY = 0.5
+)
+
+// Recomputes the scores of the poems in the explore feed and returns the
+// number of poems ranked. A poem's score grows with its overall engagement
+// and its likes and comments within the velocity window, decays with its
+// age and is lowered when its author has higher scoring poems.
+func RefreshExploreRankings() (int64, error) {
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	currentTime := time.Now().UTC()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("DELETE FROM explore_rankings;")
+	if err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(
+		`WITH stats AS (
+			SELECT poems.id, poems.user_id, poems.publish_at,
+				(SELECT COUNT(*) FROM poems_likes
+					WHERE poems_likes.poem_id=poems.id) AS likes_count,
+				(SELECT COUNT(*) FROM poems_likes
+					WHERE poems_likes.poem_id=poems.id
+						AND poems_likes.created_on>$2) AS recent_likes_count,
+				(SELECT COUNT(*) FROM comments
+					WHERE comments.poem_id=poems.id
+						AND comments.is_deleted=FALSE) AS comments_count,
+				(SELECT COUNT(*) FROM comments
+					WHERE comments.poem_id=poems.id AND comments.is_deleted=FALSE
+						AND comments.created_on>$2) AS recent_comments_count
+			FROM poems WHERE poems.status='published'
+		), scores AS (
+			SELECT id, user_id,
+				(1 + ln(1 + likes_count + 2 * comments_count)
+					+ recent_likes_count + 2 * recent_comments_count)
+				* power(0.5::float8, GREATEST(EXTRACT(EPOCH FROM ($1 - publish_at)), 0) / 3600 / $3::float8)
+				AS score
+			FROM stats
+		), diversified AS (
+			SELECT id,
+				score * power($4::float8, (ROW_NUMBER() OVER (
+					PARTITION BY user_id ORDER BY score DESC, id
+				) - 1)::float8) AS score
+			FROM scores
+		)
+		INSERT INTO explore_rankings (poem_id, score, ranked_on)
+		SELECT id, score, $1 FROM diversified
+		ORDER BY score DESC, id LIMIT $5;`,
+		currentTime,
+		currentTime.Add(-EXPLORE_VELOCITY_WINDOW),
+		EXPLORE_HALF_LIFE_HOURS,
+		EXPLORE_AUTHOR_DIVERSITY_DECAY,
+		EXPLORE_MAX_RANKED_POEMS,
+	)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Starts a background worker that periodically refreshes the explore feed's rankings.
+func StartExploreRanker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			_, err := RefreshExploreRankings()
+			if err != nil {
+				log.Println("explore ranker:", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/notifications"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/timelines"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+)
+
+const (
+	// The interval between checks for scheduled poems that are due.
+	POEM_PUBLISHER_INTERVAL = time.Minute
+)
+
+// Publishes scheduled poems whose publishing time has passed, adds them
+// to timelines, announces them to the followers of their authors and
+// notifies the users mentioned in them.
+func PublishScheduledPoems() (int64, error) {
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	currentTime := time.Now().UTC()
+	rows, err := tx.Query(
+		`UPDATE poems SET status='published'
+		WHERE status='scheduled' AND publish_at<=$1
+		RETURNING id, user_id;`,
+		currentTime,
+	)
+	if err != nil {
+		return 0, err
+	}
+	publishedEvents := []events.Event{}
+	for rows.Next() {
+		event := events.Event{Type: events.EventPoemCreated}
+		err = rows.Scan(&event.PoemId, &event.AuthorId)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		publishedEvents = append(publishedEvents, event)
+	}
+	rows.Close()
+	for _, event := range publishedEvents {
+		err = notifications.AddMentions(tx, event.AuthorId, event.PoemId, "", currentTime)
+		if err != nil {
+			return 0, err
+		}
+		err = timelines.AddPoem(tx, event.PoemId, event.AuthorId)
+		if err != nil {
+			return 0, err
+		}
+		err = events.Publish(tx, event)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range publishedEvents {
+		poem := &db_models.Poem{}
+		err = db.Get(poem, "SELECT * FROM poems WHERE id=$1;", event.PoemId)
+		if err != nil {
+			log.Println("poem publisher:", err)
+			continue
+		}
+		search.SyncPoem(poem)
+	}
+	return int64(len(publishedEvents)), nil
+}
+
+// Starts a background worker that periodically publishes scheduled poems.
+func StartPoemPublisher(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			count, err := PublishScheduledPoems()
+			if err != nil {
+				log.Println("poem publisher:", err)
+			} else if count > 0 {
+				log.Printf("poem publisher: published %d poem(s)\n", count)
+			}
+		}
+	}()
+}
@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// The interval between checks for webhook deliveries that are due.
+	WEBHOOK_DELIVERER_INTERVAL = 10 * time.Second
+	// The maximum number of webhook deliveries sent in one check.
+	WEBHOOK_DELIVERIES_BATCH_SIZE = 50
+	// The maximum time to wait for a webhook endpoint to respond.
+	WEBHOOK_TIMEOUT = 10 * time.Second
+	// The maximum number of attempts at delivering an event to a webhook.
+	WEBHOOK_MAX_ATTEMPTS = 8
+	// The delay before the first retry of a failed delivery, which doubles
+	// with every subsequent attempt.
+	WEBHOOK_RETRY_BASE_DELAY = 30 * time.Second
+)
+
+var webhookClient = &http.Client{
+	Timeout: WEBHOOK_TIMEOUT,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: WEBHOOK_TIMEOUT,
+			Control: checkWebhookAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: WEBHOOK_TIMEOUT,
+	},
+	// redirects aren't followed so deliveries only reach the registered URL
+	CheckRedirect: func(request *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Checks the address a webhook delivery is about to connect to, which catches
+// hosts that started resolving to internal addresses after being registered.
+func checkWebhookAddress(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !utils.IsPublicIP(ip) {
+		return fmt.Errorf("Webhook URL resolves to the internal address %s.", host)
+	}
+	return nil
+}
+
+// Sends a delivery's payload to its webhook and returns
+// the response's status code.
+func sendWebhookDelivery(webhook *db_models.Webhook, delivery *db_models.WebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+	timestamp := time.Now().UTC().Unix()
+	request, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Cartedepoezii-Webhooks/1.0")
+	request.Header.Set("X-Cartedepoezii-Event", delivery.EventType)
+	request.Header.Set("X-Cartedepoezii-Delivery", delivery.Id)
+	request.Header.Set("X-Cartedepoezii-Timestamp", fmt.Sprint(timestamp))
+	request.Header.Set(
+		"X-Cartedepoezii-Signature",
+		utils.SignWebhookPayload(webhook.Secret, timestamp, payload),
+	)
+	response, err := webhookClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("Endpoint responded with status %d.", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// Attempts a webhook delivery and records its outcome. Failed deliveries
+// are retried with an exponential backoff until they run out of attempts.
+func attemptWebhookDelivery(db *sqlx.DB, delivery *db_models.WebhookDelivery) error {
+	webhook := &db_models.Webhook{}
+	err := db.Get(webhook, "SELECT * FROM webhooks WHERE id=$1;", delivery.WebhookId)
+	responseCode := 0
+	if err != nil || !webhook.IsActive {
+		err = errors.New("Webhook is inactive.")
+		delivery.Attempts = WEBHOOK_MAX_ATTEMPTS
+	} else {
+		responseCode, err = sendWebhookDelivery(webhook, delivery)
+		delivery.Attempts++
+	}
+	currentTime := time.Now().UTC()
+	status := db_models.WebhookDeliveryStatusSucceeded
+	errorText := ""
+	nextAttemptOn := delivery.NextAttemptOn
+	if err != nil {
+		errorText = err.Error()
+		if delivery.Attempts >= WEBHOOK_MAX_ATTEMPTS {
+			status = db_models.WebhookDeliveryStatusFailed
+		} else {
+			status = db_models.WebhookDeliveryStatusPending
+			nextAttemptOn = currentTime.Add(WEBHOOK_RETRY_BASE_DELAY << (delivery.Attempts - 1))
+		}
+	}
+	_, err = db.Exec(
+		`UPDATE webhook_deliveries SET
+			status=$1, attempts=$2, next_attempt_on=$3, response_code=$4, error=$5, updated_on=$6
+			WHERE id=$7;`,
+		status,
+		delivery.Attempts,
+		nextAttemptOn,
+		responseCode,
+		errorText,
+		currentTime,
+		delivery.Id,
+	)
+	return err
+}
+
+// Claims a webhook delivery that is due so other server instances don't
+// send it too. The claim lasts long enough for one delivery attempt, after
+// which the delivery is due again if this instance didn't record its outcome.
+func claimWebhookDelivery(db *sqlx.DB) (*db_models.WebhookDelivery, error) {
+	currentTime := time.Now().UTC()
+	deliveries := []db_models.WebhookDelivery{}
+	err := db.Select(
+		&deliveries,
+		`UPDATE webhook_deliveries SET next_attempt_on=$1
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status='pending' AND next_attempt_on<=$2
+			ORDER BY next_attempt_on LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *;`,
+		currentTime.Add(WEBHOOK_TIMEOUT*2),
+		currentTime,
+	)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// Sends the webhook deliveries that are due and returns the number attempted.
+func DeliverWebhooks() (int, error) {
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	for i := 0; i < WEBHOOK_DELIVERIES_BATCH_SIZE; i++ {
+		delivery, err := claimWebhookDelivery(db)
+		if err != nil {
+			return i, err
+		}
+		if delivery == nil {
+			return i, nil
+		}
+		err = attemptWebhookDelivery(db, delivery)
+		if err != nil {
+			return i + 1, err
+		}
+	}
+	return WEBHOOK_DELIVERIES_BATCH_SIZE, nil
+}
+
+// Starts a background worker that periodically sends the webhook deliveries that are due.
+func StartWebhookDeliverer(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			_, err := DeliverWebhooks()
+			if err != nil {
+				log.Println("webhook deliverer:", err)
+			}
+		}
+	}()
+}
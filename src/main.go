@@ -2,13 +2,22 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/configs"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/jobs"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/search"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		reindex()
+		return
+	}
 	server := gin.Default()
 	host := "0.0.0.0"
 
@@ -16,5 +25,27 @@ func main() {
 		host = os.Getenv("")
 	}
 	configs.AddEndpoints(server)
+	jobs.StartPoemPublisher(jobs.POEM_PUBLISHER_INTERVAL)
+	jobs.StartDigestMailer(jobs.DIGEST_MAILER_INTERVAL, utils.GetMailer())
+	jobs.StartWebhookDeliverer(jobs.WEBHOOK_DELIVERER_INTERVAL)
+	jobs.StartExploreRanker(jobs.EXPLORE_RANKER_INTERVAL)
+	events.StartListener()
 	server.Run(fmt.Sprintf("%s:5000", host))
 }
+
+// Rebuilds the search index from the database.
+func reindex() {
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		log.Fatal(err)
+	}
+	index, err := search.GetIndex()
+	if err != nil {
+		log.Fatal(err)
+	}
+	count, err := search.Reindex(db, index)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("reindex: indexed %d document(s)\n", count)
+}
@@ -0,0 +1,134 @@
+package notifications
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/events"
+	"github.com/google/uuid"
+)
+
+// Creates the key of the group of notifications about the same target.
+func GetGroupKey(notificationType, targetId string) string {
+	return notificationType + ":" + targetId
+}
+
+// Saves a notification for a user about another user's action.
+// Repeated reactions and follows by an actor replace the previous ones.
+func Add(tx *sql.Tx, notification db_models.Notification) error {
+	if notification.UserId == notification.ActorId {
+		return nil
+	}
+	if notification.Type == db_models.NotificationTypeReaction ||
+		notification.Type == db_models.NotificationTypeFollow {
+		err := Remove(
+			tx,
+			notification.Type,
+			notification.UserId,
+			notification.ActorId,
+			notification.PoemId,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if len(notification.Id) == 0 {
+		notification.Id = uuid.New().String()
+	}
+	_, err := tx.Exec(
+		`INSERT INTO notifications (
+			id, user_id, actor_id, type, poem_id, comment_id, group_key, created_on
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`,
+		notification.Id,
+		notification.UserId,
+		notification.ActorId,
+		notification.Type,
+		notification.PoemId,
+		notification.CommentId,
+		notification.GroupKey,
+		notification.CreatedOn,
+	)
+	if err != nil {
+		return err
+	}
+	return events.Publish(tx, events.Event{
+		Type:           events.EventNotificationCreated,
+		UserId:         notification.UserId,
+		AuthorId:       notification.ActorId,
+		PoemId:         notification.PoemId,
+		CommentId:      notification.CommentId,
+		NotificationId: notification.Id,
+	})
+}
+
+// Removes the notifications about an action that was undone.
+func Remove(tx *sql.Tx, notificationType, userId, actorId, poemId string) error {
+	_, err := tx.Exec(
+		`DELETE FROM notifications
+		WHERE type=$1 AND user_id=$2 AND actor_id=$3 AND poem_id=$4;`,
+		notificationType,
+		userId,
+		actorId,
+		poemId,
+	)
+	return err
+}
+
+// Notifies the users mentioned in a poem's verses or in a comment.
+// Users who were already notified of being mentioned in it aren't
+// notified again, so that edits only notify the newly mentioned users,
+// and users who have a block with the author aren't notified.
+func AddMentions(
+	tx *sql.Tx,
+	authorId, poemId, commentId string,
+	currentTime time.Time,
+) error {
+	rows, err := tx.Query(
+		`SELECT DISTINCT user_id FROM mentions
+		WHERE poem_id=$1 AND comment_id=$2 AND user_id NOT IN (
+			SELECT user_id FROM notifications
+			WHERE type=$3 AND poem_id=$1 AND comment_id=$2
+		) AND NOT EXISTS (
+			SELECT 1 FROM users_blocks
+			WHERE (blocker_id=$4 AND blocked_id=mentions.user_id)
+				OR (blocker_id=mentions.user_id AND blocked_id=$4)
+		);`,
+		poemId,
+		commentId,
+		db_models.NotificationTypeMention,
+		authorId,
+	)
+	if err != nil {
+		return err
+	}
+	userIds := []string{}
+	for rows.Next() {
+		userId := ""
+		err = rows.Scan(&userId)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		userIds = append(userIds, userId)
+	}
+	rows.Close()
+	for _, userId := range userIds {
+		notificationId := uuid.New().String()
+		err = Add(tx, db_models.Notification{
+			Id:        notificationId,
+			UserId:    userId,
+			ActorId:   authorId,
+			Type:      db_models.NotificationTypeMention,
+			PoemId:    poemId,
+			CommentId: commentId,
+			GroupKey:  GetGroupKey(db_models.NotificationTypeMention, notificationId),
+			CreatedOn: currentTime,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ type SignInForm struct {
 
 type SignUpForm struct {
 	Name     string `json:"name" binding:"required"`
+	Handle   string `json:"handle" binding:"-"`
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
@@ -0,0 +1,7 @@
+package request_models
+
+type BookmarkForm struct {
+	AuthToken string `json:"authToken" binding:"required"`
+	UserId    string `json:"userId" binding:"required"`
+	PoemId    string `json:"poemId" binding:"required"`
+}
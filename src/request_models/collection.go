@@ -0,0 +1,39 @@
+package request_models
+
+type CollectionAddForm struct {
+	AuthToken   string `json:"authToken" binding:"required"`
+	UserId      string `json:"userId" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description" binding:"-"`
+	Visibility  string `json:"visibility" binding:"required"`
+}
+
+type CollectionUpdateForm struct {
+	AuthToken    string `json:"authToken" binding:"required"`
+	UserId       string `json:"userId" binding:"required"`
+	CollectionId string `json:"collectionId" binding:"required"`
+	Title        string `json:"title" binding:"required"`
+	Description  string `json:"description" binding:"-"`
+	Visibility   string `json:"visibility" binding:"required"`
+}
+
+type CollectionDeleteForm struct {
+	AuthToken    string `json:"authToken" binding:"required"`
+	UserId       string `json:"userId" binding:"required"`
+	CollectionId string `json:"collectionId" binding:"required"`
+}
+
+type CollectionPoemForm struct {
+	AuthToken    string `json:"authToken" binding:"required"`
+	UserId       string `json:"userId" binding:"required"`
+	CollectionId string `json:"collectionId" binding:"required"`
+	PoemId       string `json:"poemId" binding:"required"`
+	Position     *int   `json:"position" binding:"-"`
+}
+
+type CollectionOrderForm struct {
+	AuthToken    string   `json:"authToken" binding:"required"`
+	UserId       string   `json:"userId" binding:"required"`
+	CollectionId string   `json:"collectionId" binding:"required"`
+	PoemIds      []string `json:"poemIds" binding:"required"`
+}
@@ -1,11 +1,27 @@
 package request_models
 
 type CommentAddForm struct {
+	AuthToken  string `json:"authToken" binding:"required"`
+	UserId     string `json:"userId" binding:"required"`
+	PoemId     string `json:"poemId" binding:"required"`
+	Text       string `json:"text" binding:"required"`
+	ReplyTo    string `json:"replyTo" binding:"-"`
+	VerseIndex *int   `json:"verseIndex" binding:"-"`
+	RangeStart *int   `json:"rangeStart" binding:"-"`
+	RangeEnd   *int   `json:"rangeEnd" binding:"-"`
+}
+
+type CommentUpdateForm struct {
 	AuthToken string `json:"authToken" binding:"required"`
 	UserId    string `json:"userId" binding:"required"`
-	PoemId    string `json:"poemId" binding:"required"`
+	CommentId string `json:"commentId" binding:"required"`
 	Text      string `json:"text" binding:"required"`
-	ReplyTo   string `json:"replyTo" binding:"-"`
+}
+
+type CommentLikeForm struct {
+	AuthToken string `json:"authToken" binding:"required"`
+	UserId    string `json:"userId" binding:"required"`
+	CommentId string `json:"commentId" binding:"required"`
 }
 
 type CommentDeleteForm struct {
@@ -5,3 +5,9 @@ type ConnectionForm struct {
 	UserId    string `json:"userId" binding:"required"`
 	FollowId  string `json:"followId" binding:"required"`
 }
+
+type BlockForm struct {
+	AuthToken string `json:"authToken" binding:"required"`
+	UserId    string `json:"userId" binding:"required"`
+	BlockId   string `json:"blockId" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package request_models
+
+type NotificationsReadForm struct {
+	AuthToken       string   `json:"authToken" binding:"required"`
+	UserId          string   `json:"userId" binding:"required"`
+	NotificationIds []string `json:"notificationIds" binding:"-"`
+}
@@ -1,18 +1,41 @@
 package request_models
 
+import "github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+
 type PoemAddForm struct {
-	AuthToken string   `json:"authToken" binding:"required"`
-	UserId    string   `json:"userId" binding:"required"`
-	Title     string   `json:"title" binding:"required"`
-	Verses    []string `json:"verses" binding:"required"`
+	AuthToken string          `json:"authToken" binding:"required"`
+	UserId    string          `json:"userId" binding:"required"`
+	Title     string          `json:"title" binding:"required"`
+	Verses    []string        `json:"verses" binding:"-"`
+	Body      *utils.PoemBody `json:"body" binding:"-"`
+	Tags      []string        `json:"tags" binding:"-"`
+	Language  string          `json:"language" binding:"-"`
+	Status    string          `json:"status" binding:"-"`
+	PublishAt string          `json:"publishAt" binding:"-"`
 }
 
 type PoemUpdateForm struct {
-	AuthToken string   `json:"authToken" binding:"required"`
-	UserId    string   `json:"userId" binding:"required"`
-	PoemId    string   `json:"poemId" binding:"required"`
-	Title     string   `json:"title" binding:"required"`
-	Verses    []string `json:"verses" binding:"required"`
+	AuthToken string          `json:"authToken" binding:"required"`
+	UserId    string          `json:"userId" binding:"required"`
+	PoemId    string          `json:"poemId" binding:"required"`
+	Title     string          `json:"title" binding:"required"`
+	Verses    []string        `json:"verses" binding:"-"`
+	Body      *utils.PoemBody `json:"body" binding:"-"`
+	Tags      []string        `json:"tags" binding:"-"`
+	Language  string          `json:"language" binding:"-"`
+}
+
+type PoemDraftUpdateForm struct {
+	AuthToken string          `json:"authToken" binding:"required"`
+	UserId    string          `json:"userId" binding:"required"`
+	PoemId    string          `json:"poemId" binding:"required"`
+	Title     string          `json:"title" binding:"required"`
+	Verses    []string        `json:"verses" binding:"-"`
+	Body      *utils.PoemBody `json:"body" binding:"-"`
+	Tags      []string        `json:"tags" binding:"-"`
+	Language  string          `json:"language" binding:"-"`
+	Status    string          `json:"status" binding:"required"`
+	PublishAt string          `json:"publishAt" binding:"-"`
 }
 
 type PoemDeleteForm struct {
@@ -25,4 +48,12 @@ type PoemLikeForm struct {
 	AuthToken string `json:"authToken" binding:"required"`
 	UserId    string `json:"userId" binding:"required"`
 	PoemId    string `json:"poemId" binding:"required"`
+	Reaction  string `json:"reaction" binding:"-"`
+}
+
+type PoemRevisionRestoreForm struct {
+	AuthToken  string `json:"authToken" binding:"required"`
+	UserId     string `json:"userId" binding:"required"`
+	PoemId     string `json:"poemId" binding:"required"`
+	RevisionId string `json:"revisionId" binding:"required"`
 }
@@ -0,0 +1,6 @@
+package request_models
+
+type SearchReindexForm struct {
+	AuthToken string `json:"authToken" binding:"required"`
+	UserId    string `json:"userId" binding:"required"`
+}
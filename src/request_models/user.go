@@ -5,6 +5,18 @@ type UserDeleteForm struct {
 	UserId    string `json:"userId" binding:"required"`
 }
 
+type UserHandleUpdateForm struct {
+	AuthToken string `json:"authToken" binding:"required"`
+	UserId    string `json:"userId" binding:"required"`
+	Handle    string `json:"handle" binding:"required"`
+}
+
+type UserPreferencesForm struct {
+	AuthToken       string `json:"authToken" binding:"required"`
+	UserId          string `json:"userId" binding:"required"`
+	DigestFrequency string `json:"digestFrequency" binding:"required"`
+}
+
 type UserUpdateForm struct {
 	AuthToken          string `json:"authToken" binding:"required"`
 	UserId             string `json:"userId" binding:"required"`
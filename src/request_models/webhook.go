@@ -0,0 +1,24 @@
+package request_models
+
+type WebhookAddForm struct {
+	AuthToken  string   `json:"authToken" binding:"required"`
+	UserId     string   `json:"userId" binding:"required"`
+	Url        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"eventTypes" binding:"required"`
+	Scope      string   `json:"scope" binding:"-"`
+}
+
+type WebhookUpdateForm struct {
+	AuthToken  string   `json:"authToken" binding:"required"`
+	UserId     string   `json:"userId" binding:"required"`
+	WebhookId  string   `json:"webhookId" binding:"required"`
+	Url        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"eventTypes" binding:"required"`
+	IsActive   bool     `json:"isActive" binding:"-"`
+}
+
+type WebhookDeleteForm struct {
+	AuthToken string `json:"authToken" binding:"required"`
+	UserId    string `json:"userId" binding:"required"`
+	WebhookId string `json:"webhookId" binding:"required"`
+}
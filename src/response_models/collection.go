@@ -0,0 +1,12 @@
+package response_models
+
+type Collection struct {
+	Id          string  `json:"id"`
+	User        UserMin `json:"user"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Visibility  string  `json:"visibility"`
+	PoemsCount  int     `json:"poemsCount"`
+	CreatedOn   string  `json:"createdOn"`
+	UpdatedOn   string  `json:"updatedOn"`
+}
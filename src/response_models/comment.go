@@ -1,11 +1,33 @@
 package response_models
 
 type Comment struct {
-	Id           string  `json:"id"`
-	User         UserMin `json:"user"`
-	CreatedOn    string  `json:"createdOn"`
-	Text         string  `json:"text"`
-	PoemId       string  `json:"poemId"`
-	RepliesCount int     `json:"repliesCount"`
-	ReplyTo      string  `json:"replyTo"`
+	Id           string         `json:"id"`
+	User         UserMin        `json:"user"`
+	CreatedOn    string         `json:"createdOn"`
+	Text         string         `json:"text"`
+	PoemId       string         `json:"poemId"`
+	RepliesCount int            `json:"repliesCount"`
+	ReplyTo      string         `json:"replyTo"`
+	Anchor       *CommentAnchor `json:"anchor"`
+	Depth        int            `json:"depth"`
+	IsDeleted    bool           `json:"isDeleted"`
+	UpdatedOn    string         `json:"updatedOn"`
+	IsEdited     bool           `json:"isEdited"`
+	LikesCount   int            `json:"likesCount"`
+	IsLiked      bool           `json:"isLiked"`
+	Mentions     []Mention      `json:"mentions"`
+}
+
+type CommentThread struct {
+	Comment
+	Replies        []CommentThread `json:"replies"`
+	HasMoreReplies bool            `json:"hasMoreReplies"`
+}
+
+type CommentAnchor struct {
+	VerseIndex int    `json:"verseIndex"`
+	RangeStart int    `json:"rangeStart"`
+	RangeEnd   int    `json:"rangeEnd"`
+	Text       string `json:"text"`
+	IsOrphaned bool   `json:"isOrphaned"`
 }
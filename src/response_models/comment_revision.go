@@ -0,0 +1,8 @@
+package response_models
+
+type CommentRevision struct {
+	Id        string `json:"id"`
+	CommentId string `json:"commentId"`
+	Text      string `json:"text"`
+	CreatedOn string `json:"createdOn"`
+}
@@ -0,0 +1,22 @@
+package response_models
+
+type Mention struct {
+	UserId string `json:"userId"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+}
+
+type VerseMention struct {
+	VerseIndex int    `json:"verseIndex"`
+	UserId     string `json:"userId"`
+	Offset     int    `json:"offset"`
+	Length     int    `json:"length"`
+}
+
+type UserMention struct {
+	Id        string  `json:"id"`
+	Author    UserMin `json:"author"`
+	PoemId    string  `json:"poemId"`
+	CommentId string  `json:"commentId"`
+	CreatedOn string  `json:"createdOn"`
+}
@@ -0,0 +1,13 @@
+package response_models
+
+type Notification struct {
+	Id          string    `json:"id"`
+	Type        string    `json:"type"`
+	Actors      []UserMin `json:"actors"`
+	ActorsCount int       `json:"actorsCount"`
+	PoemId      string    `json:"poemId"`
+	CommentId   string    `json:"commentId"`
+	Message     string    `json:"message"`
+	IsRead      bool      `json:"isRead"`
+	CreatedOn   string    `json:"createdOn"`
+}
@@ -1,12 +1,40 @@
 package response_models
 
+import "github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+
 type Poem struct {
-	Id            string   `json:"id"`
-	User          UserMin  `json:"user"`
-	Title         string   `json:"title"`
-	PublishedOn   string   `json:"publishedOn"`
-	Verses        []string `json:"verses"`
-	CommentsCount int      `json:"commentsCount"`
-	LikesCount    int      `json:"likesCount"`
-	IsLiked       bool     `json:"isLiked"`
+	Id            string         `json:"id"`
+	User          UserMin        `json:"user"`
+	Title         string         `json:"title"`
+	PublishedOn   string         `json:"publishedOn"`
+	Verses        []string       `json:"verses"`
+	Body          utils.PoemBody `json:"body"`
+	Tags          []string       `json:"tags"`
+	Status        string         `json:"status"`
+	Language      string         `json:"language"`
+	CommentsCount int            `json:"commentsCount"`
+	LikesCount    int            `json:"likesCount"`
+	Reactions     map[string]int `json:"reactions"`
+	IsLiked       bool           `json:"isLiked"`
+	Reaction      string         `json:"reaction"`
+	IsBookmarked  bool           `json:"isBookmarked"`
+	Mentions      []VerseMention `json:"mentions"`
+}
+
+type PoemReaction struct {
+	User      UserMin `json:"user"`
+	Reaction  string  `json:"reaction"`
+	CreatedOn string  `json:"createdOn"`
+}
+
+type PoemSearchResult struct {
+	Poem
+	TitleHighlight  string  `json:"titleHighlight"`
+	VersesHighlight string  `json:"versesHighlight"`
+	Rank            float64 `json:"rank"`
+}
+
+type PoemTitle struct {
+	Id    string `json:"id" db:"id"`
+	Title string `json:"title" db:"title"`
 }
@@ -0,0 +1,12 @@
+package response_models
+
+import "github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+
+type PoemRevision struct {
+	Id        string         `json:"id"`
+	PoemId    string         `json:"poemId"`
+	Title     string         `json:"title"`
+	Verses    []string       `json:"verses"`
+	Body      utils.PoemBody `json:"body"`
+	CreatedOn string         `json:"createdOn"`
+}
@@ -0,0 +1,16 @@
+package response_models
+
+type TagCount struct {
+	Name       string `json:"name" db:"name"`
+	PoemsCount int    `json:"poemsCount" db:"poems_count"`
+}
+
+type AuthorFacet struct {
+	User  UserMin `json:"user"`
+	Count int     `json:"count"`
+}
+
+type YearFacet struct {
+	Year  int `json:"year" db:"year"`
+	Count int `json:"count" db:"count"`
+}
@@ -4,6 +4,7 @@ type User struct {
 	Id              string `json:"id"`
 	Joined          string `json:"joined"`
 	Name            string `json:"name"`
+	Handle          string `json:"handle"`
 	Email           string `json:"email"`
 	Bio             string `json:"bio"`
 	ProfilePhotoId  string `json:"profilePhotoId"`
@@ -16,8 +17,21 @@ type User struct {
 }
 
 type UserMin struct {
-	Id             string `json:"id"`
-	Name           string `json:"name"`
-	ProfilePhotoId string `json:"profilePhotoId"`
+	Id             string `json:"id" db:"id"`
+	Name           string `json:"name" db:"name"`
+	Handle         string `json:"handle" db:"handle"`
+	ProfilePhotoId string `json:"profilePhotoId" db:"profile_photo_id"`
 	IsFollowing    bool   `json:"isFollowing"`
 }
+
+type PersonSuggestion struct {
+	User   UserMin `json:"user"`
+	Reason string  `json:"reason"`
+}
+
+type UserSearchResult struct {
+	UserMin
+	NameHighlight string  `json:"nameHighlight"`
+	BioHighlight  string  `json:"bioHighlight"`
+	Rank          float64 `json:"rank"`
+}
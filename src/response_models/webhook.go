@@ -0,0 +1,24 @@
+package response_models
+
+type Webhook struct {
+	Id         string   `json:"id"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Scope      string   `json:"scope"`
+	IsActive   bool     `json:"isActive"`
+	CreatedOn  string   `json:"createdOn"`
+	UpdatedOn  string   `json:"updatedOn"`
+}
+
+type WebhookDelivery struct {
+	Id            string `json:"id"`
+	EventType     string `json:"eventType"`
+	Payload       string `json:"payload"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	ResponseCode  int    `json:"responseCode"`
+	Error         string `json:"error"`
+	NextAttemptOn string `json:"nextAttemptOn"`
+	CreatedOn     string `json:"createdOn"`
+	UpdatedOn     string `json:"updatedOn"`
+}
@@ -0,0 +1,449 @@
+package search
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"unicode"
+)
+
+const (
+	// The name of the file an embedded index is stored in.
+	EMBEDDED_INDEX_FILE = "index.gob"
+	// The name of the file the changes made to an embedded index since
+	// it was last stored are appended to.
+	EMBEDDED_LOG_FILE = "index.log"
+	// The name of the file locked by the process using an embedded index.
+	EMBEDDED_LOCK_FILE = "index.lock"
+	// The number of logged changes after which they are compacted
+	// into the index's file and the log is emptied.
+	EMBEDDED_COMPACTION_THRESHOLD = 10000
+	// The maximum size of a logged change, which holds a whole document.
+	EMBEDDED_MAX_CHANGE_SIZE = 16 * 1024 * 1024
+	// The weight of a term found in a document's title over its body.
+	EMBEDDED_TITLE_WEIGHT = 2
+	// The minimum length of the last term of a query to be matched by prefix.
+	EMBEDDED_MIN_PREFIX_LENGTH = 3
+	// The minimum length of a term of a query to be matched by similar spelling.
+	EMBEDDED_MIN_FUZZY_LENGTH = 4
+	// The minimum length of a term of a query to be matched with two
+	// misspellings rather than one.
+	EMBEDDED_MIN_TWO_EDITS_LENGTH = 8
+	// The weight of a term matched by similar spelling over an exact match.
+	EMBEDDED_FUZZY_WEIGHT = 0.5
+	// The saturation of the frequency of a term in the BM25 ranking.
+	EMBEDDED_BM25_K1 = 1.2
+	// The normalization of the length of a document in the BM25 ranking.
+	EMBEDDED_BM25_B = 0.75
+)
+
+// Represents the number of times a term appears in a document.
+type embeddedPosting struct {
+	TitleFrequency int
+	BodyFrequency  int
+}
+
+// Represents an indexed document.
+type embeddedDocument struct {
+	Terms    []string
+	Length   int
+	Language string
+}
+
+// Represents the contents of an embedded index as it is stored.
+type embeddedIndexData struct {
+	// The indexed documents, keyed by kind then by id.
+	Documents map[string]map[string]embeddedDocument
+	// The documents containing each term, keyed by term, kind then id.
+	Postings map[string]map[string]map[string]embeddedPosting
+	// The sum of the lengths of the documents of each kind.
+	TotalLengths map[string]int
+}
+
+// Represents a change made to an embedded index, as it is logged.
+type embeddedChange struct {
+	// The indexed document, or nil if the change removed a document.
+	Document *Document `json:"document,omitempty"`
+	Kind     string    `json:"kind"`
+	Id       string    `json:"id"`
+}
+
+// Represents an inverted index stored in a directory on disk. Changes are
+// appended to a log, which is compacted into the index's file once it grows
+// long enough. Only one process can use the index at a time, so it can't be
+// shared by several server instances. Words aren't stemmed, so a query's
+// language only limits the documents it matches.
+type EmbeddedIndex struct {
+	mutex        sync.RWMutex
+	path         string
+	data         embeddedIndexData
+	lock         *os.File
+	log          *os.File
+	changesCount int
+}
+
+// Opens the embedded index stored in a directory, or creates an empty one,
+// and locks it for the current process.
+func OpenEmbeddedIndex(dir string) (*EmbeddedIndex, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := os.OpenFile(filepath.Join(dir, EMBEDDED_LOCK_FILE), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		lock.Close()
+		return nil, errors.New("Search index is in use by another process.")
+	}
+	index := &EmbeddedIndex{
+		path: filepath.Join(dir, EMBEDDED_INDEX_FILE),
+		data: newEmbeddedIndexData(),
+		lock: lock,
+	}
+	err = index.load()
+	if err == nil {
+		index.log, err = os.OpenFile(
+			filepath.Join(dir, EMBEDDED_LOG_FILE),
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND,
+			0644,
+		)
+	}
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return index, nil
+}
+
+// Reads the index's file and replays the changes logged since it was written.
+// A change cut short by a crash while it was being logged is ignored.
+func (index *EmbeddedIndex) load() error {
+	file, err := os.Open(index.path)
+	if err == nil {
+		err = gob.NewDecoder(file).Decode(&index.data)
+		file.Close()
+		if err != nil {
+			return errors.New("Failed to read search index, reindex to rebuild it.")
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	file, err = os.Open(filepath.Join(filepath.Dir(index.path), EMBEDDED_LOG_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, EMBEDDED_MAX_CHANGE_SIZE)
+	for scanner.Scan() {
+		change := embeddedChange{}
+		if json.Unmarshal(scanner.Bytes(), &change) != nil {
+			break
+		}
+		index.apply(change)
+		index.changesCount++
+	}
+	return nil
+}
+
+// Creates the contents of an empty embedded index.
+func newEmbeddedIndexData() embeddedIndexData {
+	return embeddedIndexData{
+		Documents:    make(map[string]map[string]embeddedDocument),
+		Postings:     make(map[string]map[string]map[string]embeddedPosting),
+		TotalLengths: make(map[string]int),
+	}
+}
+
+// Splits a text into lowercase words.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Checks if an indexed term is a misspelling of a query's term, which can
+// have one edit, or two once it is long enough.
+func isSimilarTerm(queryTerm, term string) bool {
+	queryRunes, runes := []rune(queryTerm), []rune(term)
+	if len(queryRunes) < EMBEDDED_MIN_FUZZY_LENGTH {
+		return false
+	}
+	maxEdits := 1
+	if len(queryRunes) >= EMBEDDED_MIN_TWO_EDITS_LENGTH {
+		maxEdits = 2
+	}
+	if len(runes) < len(queryRunes)-maxEdits || len(runes) > len(queryRunes)+maxEdits {
+		return false
+	}
+	return getEditDistance(queryRunes, runes) <= maxEdits
+}
+
+// Computes the number of insertions, deletions and substitutions
+// of characters turning a text into another.
+func getEditDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// Adds documents to the index or replaces their indexed versions.
+func (index *EmbeddedIndex) Index(documents ...Document) error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	changes := make([]embeddedChange, len(documents))
+	for i := range documents {
+		changes[i] = embeddedChange{Document: &documents[i], Kind: documents[i].Kind, Id: documents[i].Id}
+		index.apply(changes[i])
+	}
+	return index.record(changes...)
+}
+
+// Applies a change to the index's contents.
+func (index *EmbeddedIndex) apply(change embeddedChange) {
+	index.remove(change.Kind, change.Id)
+	if change.Document != nil {
+		index.add(*change.Document)
+	}
+}
+
+// Adds a document to the index's contents.
+func (index *EmbeddedIndex) add(document Document) {
+	postings := make(map[string]embeddedPosting)
+	titleTerms := tokenize(document.Title)
+	bodyTerms := tokenize(document.Body)
+	for _, term := range titleTerms {
+		posting := postings[term]
+		posting.TitleFrequency++
+		postings[term] = posting
+	}
+	for _, term := range bodyTerms {
+		posting := postings[term]
+		posting.BodyFrequency++
+		postings[term] = posting
+	}
+	indexedDocument := embeddedDocument{
+		Terms:    make([]string, 0, len(postings)),
+		Length:   len(titleTerms) + len(bodyTerms),
+		Language: document.Language,
+	}
+	for term, posting := range postings {
+		indexedDocument.Terms = append(indexedDocument.Terms, term)
+		if index.data.Postings[term] == nil {
+			index.data.Postings[term] = make(map[string]map[string]embeddedPosting)
+		}
+		if index.data.Postings[term][document.Kind] == nil {
+			index.data.Postings[term][document.Kind] = make(map[string]embeddedPosting)
+		}
+		index.data.Postings[term][document.Kind][document.Id] = posting
+	}
+	if index.data.Documents[document.Kind] == nil {
+		index.data.Documents[document.Kind] = make(map[string]embeddedDocument)
+	}
+	index.data.Documents[document.Kind][document.Id] = indexedDocument
+	index.data.TotalLengths[document.Kind] += indexedDocument.Length
+}
+
+// Removes a document from the index.
+func (index *EmbeddedIndex) Delete(kind, id string) error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if !index.remove(kind, id) {
+		return nil
+	}
+	return index.record(embeddedChange{Kind: kind, Id: id})
+}
+
+// Removes every document from the index.
+func (index *EmbeddedIndex) Clear() error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	index.data = newEmbeddedIndexData()
+	return index.compact()
+}
+
+// Finds the documents of a kind containing any of a query's words, ranked
+// with BM25. The last word is also matched as the prefix of longer words
+// since it may still be being typed, and words of four letters or more also
+// match their misspellings, which weigh less.
+func (index *EmbeddedIndex) Query(query Query) ([]Hit, error) {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	documents := index.data.Documents[query.Kind]
+	if len(documents) == 0 {
+		return []Hit{}, nil
+	}
+	averageLength := float64(index.data.TotalLengths[query.Kind]) / float64(len(documents))
+	queryTerms := tokenize(query.Text)
+	// the weight of each term matching the query
+	terms := make(map[string]float64)
+	for _, term := range queryTerms {
+		terms[term] = 1
+	}
+	lastTerm := ""
+	if len(queryTerms) > 0 && len([]rune(queryTerms[len(queryTerms)-1])) >= EMBEDDED_MIN_PREFIX_LENGTH {
+		lastTerm = queryTerms[len(queryTerms)-1]
+	}
+	for term := range index.data.Postings {
+		if terms[term] == 1 {
+			continue
+		}
+		if len(lastTerm) > 0 && strings.HasPrefix(term, lastTerm) {
+			terms[term] = 1
+			continue
+		}
+		for _, queryTerm := range queryTerms {
+			if isSimilarTerm(queryTerm, term) {
+				terms[term] = EMBEDDED_FUZZY_WEIGHT
+				break
+			}
+		}
+	}
+	scores := make(map[string]float64)
+	for term, weight := range terms {
+		postings := index.data.Postings[term][query.Kind]
+		if len(postings) == 0 {
+			continue
+		}
+		documentsCount := float64(len(documents))
+		frequency := float64(len(postings))
+		idf := math.Log(1 + (documentsCount-frequency+0.5)/(frequency+0.5))
+		for id, posting := range postings {
+			if len(query.Language) > 0 && documents[id].Language != query.Language {
+				continue
+			}
+			tf := float64(EMBEDDED_TITLE_WEIGHT*posting.TitleFrequency + posting.BodyFrequency)
+			norm := 1 - EMBEDDED_BM25_B + EMBEDDED_BM25_B*float64(documents[id].Length)/averageLength
+			scores[id] += weight * idf * tf * (EMBEDDED_BM25_K1 + 1) / (tf + EMBEDDED_BM25_K1*norm)
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{Id: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Id < hits[j].Id
+	})
+	if query.Limit > 0 && len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+// Removes a document from the index's contents and
+// returns whether it was indexed.
+func (index *EmbeddedIndex) remove(kind, id string) bool {
+	document, ok := index.data.Documents[kind][id]
+	if !ok {
+		return false
+	}
+	for _, term := range document.Terms {
+		delete(index.data.Postings[term][kind], id)
+		if len(index.data.Postings[term][kind]) == 0 {
+			delete(index.data.Postings[term], kind)
+		}
+		if len(index.data.Postings[term]) == 0 {
+			delete(index.data.Postings, term)
+		}
+	}
+	delete(index.data.Documents[kind], id)
+	index.data.TotalLengths[kind] -= document.Length
+	return true
+}
+
+// Appends changes to the index's log, which is compacted
+// once it holds enough changes.
+func (index *EmbeddedIndex) record(changes ...embeddedChange) error {
+	lines := []byte{}
+	for _, change := range changes {
+		line, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	_, err := index.log.Write(lines)
+	if err == nil {
+		err = index.log.Sync()
+	}
+	if err != nil {
+		return err
+	}
+	index.changesCount += len(changes)
+	if index.changesCount < EMBEDDED_COMPACTION_THRESHOLD {
+		return nil
+	}
+	return index.compact()
+}
+
+// Writes the index's contents to its file and empties its log. Replaying
+// the log after a crash between both steps leaves the contents unchanged.
+func (index *EmbeddedIndex) compact() error {
+	err := index.save()
+	if err != nil {
+		return err
+	}
+	err = index.log.Truncate(0)
+	if err != nil {
+		return err
+	}
+	index.changesCount = 0
+	return nil
+}
+
+// Writes the index's contents to a temporary file and replaces
+// the index's file with it so that a failed write leaves it intact.
+func (index *EmbeddedIndex) save() error {
+	file, err := os.CreateTemp(filepath.Dir(index.path), EMBEDDED_INDEX_FILE+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	err = gob.NewEncoder(file).Encode(&index.data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), index.path)
+}
@@ -0,0 +1,153 @@
+package search
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+)
+
+const (
+	// The kind of the documents of poems.
+	DOCUMENT_POEM = "poem"
+	// The kind of the documents of users.
+	DOCUMENT_USER = "user"
+)
+
+const (
+	// The backend searching the database's text search indexes.
+	SEARCH_BACKEND_POSTGRES = "postgres"
+	// The backend searching an inverted index stored on disk.
+	SEARCH_BACKEND_EMBEDDED = "embedded"
+	// The directory the embedded index is stored in when none is given.
+	DEFAULT_INDEX_DIR = "search_index"
+)
+
+// Represents a poem or user as it is indexed for search.
+type Document struct {
+	Kind     string
+	Id       string
+	Title    string
+	Body     string
+	Language string
+}
+
+// Represents a search for documents of a kind.
+type Query struct {
+	Kind     string
+	Text     string
+	Language string
+	Limit    int
+}
+
+// Represents a document matching a search and its relevance.
+type Hit struct {
+	Id    string  `db:"id"`
+	Score float64 `db:"score"`
+}
+
+// Represents an index that poems and users are searched with.
+type SearchIndex interface {
+	// Adds documents to the index or replaces their indexed versions.
+	Index(documents ...Document) error
+	// Removes a document from the index.
+	Delete(kind, id string) error
+	// Finds the documents matching a search, with the most relevant first.
+	Query(query Query) ([]Hit, error)
+	// Removes every document from the index.
+	Clear() error
+}
+
+var (
+	index     SearchIndex
+	indexErr  error
+	indexOnce sync.Once
+)
+
+// Retrieves the search index selected by the APP_SEARCH_BACKEND
+// environment variable, which defaults to the Postgres backend.
+func GetIndex() (SearchIndex, error) {
+	indexOnce.Do(func() {
+		switch backend := os.Getenv("APP_SEARCH_BACKEND"); backend {
+		case "", SEARCH_BACKEND_POSTGRES:
+			index = PostgresIndex{}
+		case SEARCH_BACKEND_EMBEDDED:
+			dir := os.Getenv("APP_SEARCH_INDEX_DIR")
+			if len(dir) == 0 {
+				dir = DEFAULT_INDEX_DIR
+			}
+			index, indexErr = OpenEmbeddedIndex(dir)
+		default:
+			indexErr = errors.New("Unknown search backend " + backend + ".")
+		}
+	})
+	return index, indexErr
+}
+
+// Creates the search document of a poem from its title and the
+// plain-text rendering of its body.
+func PoemDocument(poem *db_models.Poem) Document {
+	text := poem.Text
+	if body, err := utils.ParsePoemBody(poem.Text); err == nil {
+		text = body.PlainText()
+	}
+	return Document{
+		Kind:     DOCUMENT_POEM,
+		Id:       poem.Id,
+		Title:    poem.Title,
+		Body:     text,
+		Language: poem.Language,
+	}
+}
+
+// Creates the search document of a user from their name, handle and bio.
+func UserDocument(user *db_models.User) Document {
+	return Document{
+		Kind:  DOCUMENT_USER,
+		Id:    user.Id,
+		Title: user.Name + " " + user.Handle,
+		Body:  user.Bio,
+	}
+}
+
+// Updates the search index with a saved poem, which is only searchable
+// once published. Failures are logged since the poem is already saved
+// and the index can be rebuilt with the reindex command.
+func SyncPoem(poem *db_models.Poem) {
+	index, err := GetIndex()
+	if err == nil {
+		if poem.Status == db_models.PoemStatusPublished {
+			err = index.Index(PoemDocument(poem))
+		} else {
+			err = index.Delete(DOCUMENT_POEM, poem.Id)
+		}
+	}
+	if err != nil {
+		log.Println("search index:", err)
+	}
+}
+
+// Updates the search index with a saved user.
+func SyncUser(user *db_models.User) {
+	index, err := GetIndex()
+	if err == nil {
+		err = index.Index(UserDocument(user))
+	}
+	if err != nil {
+		log.Println("search index:", err)
+	}
+}
+
+// Removes a deleted poem or user from the search index.
+func Remove(kind, id string) {
+	index, err := GetIndex()
+	if err == nil {
+		err = index.Delete(kind, id)
+	}
+	if err != nil {
+		log.Println("search index:", err)
+	}
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/utils"
+)
+
+const (
+	// The search vector of a poem, which weighs its title over its verses.
+	// Only the verses' text is read from the poem's stored body.
+	// It matches the expression of the poems' text search index.
+	POEM_SEARCH_VECTOR = `(setweight(to_tsvector(poems.language, poems.title), 'A') ||
+		setweight(jsonb_to_tsvector(poems.language, poems.text::jsonb, '["string"]'), 'B'))`
+	// The search vector of a user, which weighs their name over their bio.
+	// It matches the expression of the users' text search index.
+	USER_SEARCH_VECTOR = `(setweight(to_tsvector('english', users.name), 'A') ||
+		setweight(to_tsvector('english', users.bio), 'B'))`
+	// The relevance of a poem to a query read in the poem's language, which
+	// adds the fuzzy similarity of its title to the query's full-text rank.
+	poemSearchRank = `(ts_rank_cd(` + POEM_SEARCH_VECTOR + `, websearch_to_tsquery(poems.language, %[1]s)) +
+		word_similarity(lower(%[1]s), lower(poems.title)))`
+	// The condition of the poems matching a query by their text, or
+	// by the spelling or a lowercase prefix of their titles.
+	poemSearchCondition = `(` + POEM_SEARCH_VECTOR + ` @@ websearch_to_tsquery(poems.language, %[1]s) OR
+		lower(%[1]s) <%% lower(poems.title) OR
+		lower(poems.title) LIKE %[2]s)`
+	// The relevance of a user to a query, which adds the fuzzy
+	// similarity of their name and handle to the query's full-text rank.
+	userSearchRank = `(ts_rank_cd(` + USER_SEARCH_VECTOR + `, websearch_to_tsquery('english', %[1]s)) +
+		word_similarity(lower(%[1]s), lower(users.name)) +
+		word_similarity(lower(%[1]s), lower(users.handle)))`
+	// The condition of the users matching a query by their text, or by
+	// the spelling or a lowercase prefix of their names and handles.
+	userSearchCondition = `(` + USER_SEARCH_VECTOR + ` @@ websearch_to_tsquery('english', %[1]s) OR
+		lower(%[1]s) <%% lower(users.name) OR
+		lower(%[1]s) <%% lower(users.handle) OR
+		lower(users.name) LIKE %[2]s OR
+		lower(users.handle) LIKE %[2]s)`
+)
+
+// Creates the relevance of the poems to the query whose placeholder is given.
+func GetPoemSearchRank(queryArg string) string {
+	return fmt.Sprintf(poemSearchRank, queryArg)
+}
+
+// Creates the condition of the poems matching the query and the
+// lowercase title prefix pattern whose placeholders are given.
+func GetPoemSearchCondition(queryArg, prefixArg string) string {
+	return fmt.Sprintf(poemSearchCondition, queryArg, prefixArg)
+}
+
+// Creates the relevance of the users to the query whose placeholder is given.
+func GetUserSearchRank(queryArg string) string {
+	return fmt.Sprintf(userSearchRank, queryArg)
+}
+
+// Creates the condition of the users matching the query and the lowercase
+// name and handle prefix pattern whose placeholders are given.
+func GetUserSearchCondition(queryArg, prefixArg string) string {
+	return fmt.Sprintf(userSearchCondition, queryArg, prefixArg)
+}
+
+// Represents a search index backed by the database's own text search
+// and trigram indexes, which Postgres keeps up to date by itself.
+type PostgresIndex struct{}
+
+// Does nothing since the database indexes rows as they are saved.
+func (index PostgresIndex) Index(documents ...Document) error {
+	return nil
+}
+
+// Does nothing since the database unindexes rows as they are deleted.
+func (index PostgresIndex) Delete(kind, id string) error {
+	return nil
+}
+
+// Does nothing since the database's indexes are rebuilt with REINDEX.
+func (index PostgresIndex) Clear() error {
+	return nil
+}
+
+// Finds the published poems or the users matching a search by their text,
+// or by the prefix or spelling of their titles, names and handles.
+func (index PostgresIndex) Query(query Query) ([]Hit, error) {
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	prefix := utils.EscapeLikePattern(strings.ToLower(query.Text)) + "%"
+	hits := []Hit{}
+	if query.Kind == DOCUMENT_POEM {
+		// poems of every language are searched when no language is given
+		err = db.Select(
+			&hits,
+			`SELECT id, `+GetPoemSearchRank("$2")+` AS score FROM poems
+			WHERE status='published' AND ($1='' OR poems.language::text=$1) AND
+				`+GetPoemSearchCondition("$2", "$3")+`
+			ORDER BY score DESC, id
+			LIMIT $4;`,
+			query.Language,
+			query.Text,
+			prefix,
+			query.Limit,
+		)
+		return hits, err
+	}
+	err = db.Select(
+		&hits,
+		`SELECT id, `+GetUserSearchRank("$1")+` AS score FROM users
+		WHERE `+GetUserSearchCondition("$1", "$2")+`
+		ORDER BY score DESC, id
+		LIMIT $3;`,
+		query.Text,
+		prefix,
+		query.Limit,
+	)
+	return hits, err
+}
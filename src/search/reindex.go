@@ -0,0 +1,78 @@
+package search
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/B3zaleel/Cartedepoezii_Backend_Go/src/db_models"
+	"github.com/jmoiron/sqlx"
+)
+
+// The number of documents indexed at once when rebuilding an index.
+const REINDEX_BATCH_SIZE = 500
+
+// Prevents a search index from being rebuilt by several requests at once.
+var reindexMutex sync.Mutex
+
+// Rebuilds a search index from the published poems and the users
+// in the database and returns the number of documents indexed.
+func Reindex(db *sqlx.DB, index SearchIndex) (int, error) {
+	if !reindexMutex.TryLock() {
+		return 0, errors.New("Search index is already being rebuilt.")
+	}
+	defer reindexMutex.Unlock()
+	err := index.Clear()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	batch := make([]Document, 0, REINDEX_BATCH_SIZE)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := index.Index(batch...)
+		count += len(batch)
+		batch = batch[:0]
+		return err
+	}
+	rows, err := db.Queryx("SELECT * FROM poems WHERE status=$1 ORDER BY id;", db_models.PoemStatusPublished)
+	if err != nil {
+		return count, err
+	}
+	for rows.Next() {
+		poem := db_models.Poem{}
+		err = rows.StructScan(&poem)
+		if err == nil && len(batch) == REINDEX_BATCH_SIZE {
+			err = flush()
+		}
+		if err != nil {
+			rows.Close()
+			return count, err
+		}
+		batch = append(batch, PoemDocument(&poem))
+	}
+	if err = rows.Err(); err != nil {
+		return count, err
+	}
+	rows, err = db.Queryx("SELECT * FROM users ORDER BY id;")
+	if err != nil {
+		return count, err
+	}
+	for rows.Next() {
+		user := db_models.User{}
+		err = rows.StructScan(&user)
+		if err == nil && len(batch) == REINDEX_BATCH_SIZE {
+			err = flush()
+		}
+		if err != nil {
+			rows.Close()
+			return count, err
+		}
+		batch = append(batch, UserDocument(&user))
+	}
+	if err = rows.Err(); err != nil {
+		return count, err
+	}
+	return count, flush()
+}
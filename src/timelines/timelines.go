@@ -0,0 +1,74 @@
+package timelines
+
+import "database/sql"
+
+// The maximum number of followers an author can have for their poems to be
+// copied to their followers' timelines when published. The poems of authors
+// with more followers are read from the poems table when feeds are loaded.
+const FAN_OUT_MAX_FOLLOWERS = 10000
+
+// Adds a published poem to its author's timeline and, unless the author
+// is too popular, to the timelines of the author's followers.
+func AddPoem(tx *sql.Tx, poemId, authorId string) error {
+	followersCount := 0
+	err := tx.QueryRow(
+		"SELECT COUNT(*) FROM users_followings WHERE following_id=$1;",
+		authorId,
+	).Scan(&followersCount)
+	if err != nil {
+		return err
+	}
+	isFannedOut := followersCount <= FAN_OUT_MAX_FOLLOWERS
+	_, err = tx.Exec(
+		`INSERT INTO timelines (user_id, poem_id, author_id, publish_at)
+		SELECT user_id, id, user_id, publish_at FROM poems WHERE id=$1
+		ON CONFLICT DO NOTHING;`,
+		poemId,
+	)
+	if err != nil {
+		return err
+	}
+	if isFannedOut {
+		_, err = tx.Exec(
+			`INSERT INTO timelines (user_id, poem_id, author_id, publish_at)
+			SELECT users_followings.follower_id, poems.id, poems.user_id, poems.publish_at
+			FROM poems
+			INNER JOIN users_followings ON users_followings.following_id=poems.user_id
+			WHERE poems.id=$1
+			ON CONFLICT DO NOTHING;`,
+			poemId,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(
+		"UPDATE poems SET is_fanned_out=$1 WHERE id=$2;",
+		isFannedOut,
+		poemId,
+	)
+	return err
+}
+
+// Adds the fanned out poems of a followed author to a follower's timeline.
+func AddFollowing(tx *sql.Tx, followerId, followingId string) error {
+	_, err := tx.Exec(
+		`INSERT INTO timelines (user_id, poem_id, author_id, publish_at)
+		SELECT $1, id, user_id, publish_at FROM poems
+		WHERE user_id=$2 AND status='published' AND is_fanned_out=TRUE
+		ON CONFLICT DO NOTHING;`,
+		followerId,
+		followingId,
+	)
+	return err
+}
+
+// Removes the poems of an author a user stopped following from their timeline.
+func RemoveFollowing(tx *sql.Tx, followerId, followingId string) error {
+	_, err := tx.Exec(
+		"DELETE FROM timelines WHERE user_id=$1 AND author_id=$2;",
+		followerId,
+		followingId,
+	)
+	return err
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// The minimum length of a user's handle.
+	MinHandleLength = 3
+	// The maximum length of a user's handle.
+	MaxHandleLength = 30
+	// The minimum period between two changes of a user's handle.
+	HandleChangeCooldown = 14 * 24 * time.Hour
+	// The period in which a user's old handle redirects to their new handle.
+	HandleRedirectDuration = 30 * 24 * time.Hour
+)
+
+var (
+	handlePattern      = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	handleCharsPattern = regexp.MustCompile(`[^a-z0-9_]+`)
+	// Handles that can't be chosen because they could be confused with
+	// the app's pages or staff.
+	reservedHandles = map[string]bool{
+		"about": true, "admin": true, "administrator": true, "api": true,
+		"bookmarks": true, "cartedepoezii": true, "collections": true,
+		"explore": true, "help": true, "home": true, "login": true,
+		"logout": true, "me": true, "mentions": true, "moderator": true,
+		"notifications": true, "null": true, "poem": true, "poems": true,
+		"root": true, "search": true, "settings": true, "signin": true,
+		"signup": true, "staff": true, "support": true, "system": true,
+		"tags": true, "undefined": true, "user": true, "users": true,
+	}
+)
+
+// Checks if a handle can be chosen by a user.
+func ValidateHandle(handle string) error {
+	if len(handle) < MinHandleLength {
+		return errors.New("Handle is too short.")
+	}
+	if len(handle) > MaxHandleLength {
+		return errors.New("Handle is too long.")
+	}
+	if !handlePattern.MatchString(handle) {
+		return errors.New("Handle can only contain letters, digits and underscores.")
+	}
+	if reservedHandles[strings.ToLower(handle)] {
+		return errors.New("Handle is reserved.")
+	}
+	return nil
+}
+
+// Creates a default handle for a user from their name and id.
+func GenerateHandle(name, userId string) string {
+	base := handleCharsPattern.ReplaceAllString(strings.ToLower(name), "")
+	digest := md5.Sum([]byte(userId))
+	suffix := hex.EncodeToString(digest[:])[:6]
+	if len(base) > MaxHandleLength-len(suffix)-1 {
+		base = base[:MaxHandleLength-len(suffix)-1]
+	}
+	if len(base) == 0 {
+		base = "user"
+	}
+	return base + "_" + suffix
+}
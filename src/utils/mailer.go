@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The default directory the file mailer saves emails to.
+const DefaultMailDir = "mail"
+
+// Represents an email sent to a user.
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Represents a service that delivers emails.
+type Mailer interface {
+	Send(message MailMessage) error
+}
+
+// Represents a mailer that saves emails as files in a directory
+// instead of delivering them, for local development and testing.
+type FileMailer struct {
+	Dir    string
+	Sender string
+}
+
+// Saves an email as a .eml file in the mailer's directory.
+func (mailer FileMailer) Send(message MailMessage) error {
+	if _, err := mail.ParseAddress(message.To); err != nil {
+		return err
+	}
+	err := os.MkdirAll(mailer.Dir, 0755)
+	if err != nil {
+		return err
+	}
+	currentTime := time.Now().UTC()
+	fileName := fmt.Sprintf("%s_%s.eml", currentTime.Format("20060102T150405Z"), uuid.New().String())
+	content := strings.Join([]string{
+		"From: " + mailer.Sender,
+		"To: " + message.To,
+		"Subject: " + message.Subject,
+		"Date: " + currentTime.Format(time.RFC1123Z),
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		message.Body,
+	}, "\r\n")
+	return os.WriteFile(filepath.Join(mailer.Dir, fileName), []byte(content), 0644)
+}
+
+// Retrieves the mailer used to send emails to users.
+func GetMailer() Mailer {
+	dir := os.Getenv("APP_MAIL_DIR")
+	if len(dir) == 0 {
+		dir = DefaultMailDir
+	}
+	return FileMailer{
+		Dir:    dir,
+		Sender: os.Getenv("GOOGLE_MAIL_SENDER"),
+	}
+}
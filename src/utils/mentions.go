@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])(@[A-Za-z0-9_]{1,30})\b`)
+
+// Represents an @handle mentioned in a text.
+// Offset and Length are in runes and include the leading "@".
+type Mention struct {
+	Handle string
+	Offset int
+	Length int
+}
+
+// Retrieves the @handle mentions in a text.
+func ExtractMentions(text string) []Mention {
+	mentions := []Mention{}
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+		mentions = append(mentions, Mention{
+			Handle: text[start+1 : end],
+			Offset: utf8.RuneCountInString(text[:start]),
+			Length: utf8.RuneCountInString(text[start:end]),
+		})
+	}
+	return mentions
+}
@@ -12,7 +12,9 @@ import (
 
 // Represents an item of a page.
 type Item interface {
-	db_models.User | db_models.Poem | db_models.Comment | db_models.UserFollowing | db_models.PoemLike;
+	db_models.User | db_models.Poem | db_models.Comment | db_models.UserFollowing | db_models.PoemLike |
+		db_models.PoemRevision | db_models.Collection | db_models.Bookmark | db_models.CommentRevision |
+		db_models.Mention | db_models.Notification | db_models.Webhook | db_models.WebhookDelivery;
 	GetId() string
 }
 
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+	// The maximum number of stanzas in a poem.
+	MaxPoemStanzas = 128
+	// The maximum number of verses in a poem.
+	MaxPoemVerses = 1024
+	// The maximum indentation level of a verse.
+	MaxVerseIndent = 8
+	// The number of spaces a verse's indentation level is rendered with.
+	VerseIndentWidth = 4
+)
+
+// Represents a verse of a poem. Its text can have italic (*text*) and
+// bold (**text**) spans, with a backslash escaping an asterisk.
+type PoemVerse struct {
+	Text   string `json:"text"`
+	Indent int    `json:"indent"`
+}
+
+// Represents a group of verses of a poem.
+type PoemStanza struct {
+	Verses []PoemVerse `json:"verses"`
+}
+
+// Represents the structured text of a poem.
+type PoemBody struct {
+	Stanzas []PoemStanza `json:"stanzas"`
+}
+
+// Represents a run of a verse's text with the same formatting.
+type TextSpan struct {
+	Text     string
+	IsItalic bool
+	IsBold   bool
+}
+
+// Creates a poem's body from a flat list of verses, which form
+// a single stanza of unformatted and unindented verses.
+func NewPoemBody(verses []string) *PoemBody {
+	stanza := PoemStanza{Verses: make([]PoemVerse, len(verses))}
+	for i, verse := range verses {
+		stanza.Verses[i] = PoemVerse{Text: EscapeInlineMarkup(verse)}
+	}
+	return &PoemBody{Stanzas: []PoemStanza{stanza}}
+}
+
+// Reads a poem's stored text, which is either a structured body or the
+// flat list of verses poems were stored as before stanzas existed.
+func ParsePoemBody(text string) (*PoemBody, error) {
+	if strings.HasPrefix(strings.TrimSpace(text), "[") {
+		verses := []string{}
+		err := json.Unmarshal([]byte(text), &verses)
+		if err != nil {
+			return nil, err
+		}
+		return NewPoemBody(verses), nil
+	}
+	body := &PoemBody{}
+	err := json.Unmarshal([]byte(text), body)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Retrieves the unformatted verses of a poem's stored text.
+func GetPoemVerses(text string) ([]string, error) {
+	body, err := ParsePoemBody(text)
+	if err != nil {
+		return nil, err
+	}
+	return body.Verses(), nil
+}
+
+// Checks that a poem's body has stanzas of non-empty verses
+// with valid indentation and formatting.
+func (body *PoemBody) Validate() error {
+	if len(body.Stanzas) < 1 {
+		return errors.New("At least 1 verse is needed.")
+	}
+	if len(body.Stanzas) > MaxPoemStanzas {
+		return errors.New("Too many stanzas.")
+	}
+	versesCount := 0
+	for _, stanza := range body.Stanzas {
+		if len(stanza.Verses) < 1 {
+			return errors.New("Some stanzas are empty.")
+		}
+		versesCount += len(stanza.Verses)
+		for _, verse := range stanza.Verses {
+			if verse.Indent < 0 || verse.Indent > MaxVerseIndent {
+				return errors.New("Invalid verse indentation.")
+			}
+			spans, err := ParseInlineMarkup(verse.Text)
+			if err != nil {
+				return err
+			}
+			if len(strings.Trim(joinSpans(spans), " ")) < 1 {
+				return errors.New("Some verses are too short.")
+			}
+		}
+	}
+	if versesCount > MaxPoemVerses {
+		return errors.New("Too many verses.")
+	}
+	return nil
+}
+
+// Serializes a poem's body into its stored text.
+func (body *PoemBody) Encode() (string, error) {
+	bodyTxt, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	return string(bodyTxt), nil
+}
+
+// Retrieves the unformatted text of a poem's verses across its stanzas,
+// which annotations, mentions and hashtags are anchored to.
+func (body *PoemBody) Verses() []string {
+	verses := []string{}
+	for _, stanza := range body.Stanzas {
+		for _, verse := range stanza.Verses {
+			verses = append(verses, StripInlineMarkup(verse.Text))
+		}
+	}
+	return verses
+}
+
+// Renders a poem's body as plain text with indented verses
+// and stanzas separated by blank lines.
+func (body *PoemBody) PlainText() string {
+	stanzas := make([]string, len(body.Stanzas))
+	for i, stanza := range body.Stanzas {
+		verses := make([]string, len(stanza.Verses))
+		for j, verse := range stanza.Verses {
+			verses[j] = strings.Repeat(" ", verse.Indent*VerseIndentWidth) + StripInlineMarkup(verse.Text)
+		}
+		stanzas[i] = strings.Join(verses, "\n")
+	}
+	return strings.Join(stanzas, "\n\n")
+}
+
+// Splits a verse's text into runs of italic, bold and plain text.
+// Formatting spans must be closed and can nest but not overlap.
+func ParseInlineMarkup(text string) ([]TextSpan, error) {
+	spans := []TextSpan{}
+	current := strings.Builder{}
+	isItalic, isBold := false, false
+	// the markers of the open spans, with the innermost last
+	markers := []string{}
+	flush := func() {
+		if current.Len() > 0 {
+			spans = append(spans, TextSpan{Text: current.String(), IsItalic: isItalic, IsBold: isBold})
+			current.Reset()
+		}
+	}
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '*' || runes[i+1] == '\\') {
+			current.WriteRune(runes[i+1])
+			i++
+			continue
+		}
+		if runes[i] != '*' {
+			current.WriteRune(runes[i])
+			continue
+		}
+		stars := 1
+		for i+1 < len(runes) && runes[i+1] == '*' {
+			stars++
+			i++
+		}
+		flush()
+		for stars > 0 {
+			innermost := ""
+			if len(markers) > 0 {
+				innermost = markers[len(markers)-1]
+			}
+			switch {
+			case innermost == "**" && stars >= 2:
+				markers = markers[:len(markers)-1]
+				isBold = false
+				stars -= 2
+			case innermost == "*" && (stars == 1 || isBold):
+				markers = markers[:len(markers)-1]
+				isItalic = false
+				stars--
+			case stars >= 2 && !isBold:
+				markers = append(markers, "**")
+				isBold = true
+				stars -= 2
+			case !isItalic:
+				markers = append(markers, "*")
+				isItalic = true
+				stars--
+			default:
+				return nil, errors.New("Invalid verse formatting.")
+			}
+		}
+	}
+	if len(markers) > 0 {
+		return nil, errors.New("Invalid verse formatting.")
+	}
+	flush()
+	return spans, nil
+}
+
+// Removes the formatting of a verse's text. Text with invalid
+// formatting is returned as it is.
+func StripInlineMarkup(text string) string {
+	spans, err := ParseInlineMarkup(text)
+	if err != nil {
+		return text
+	}
+	return joinSpans(spans)
+}
+
+// Escapes the characters of a text that would be read as formatting.
+func EscapeInlineMarkup(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "*", "\\*").Replace(text)
+}
+
+// Joins the text of formatting spans.
+func joinSpans(spans []TextSpan) string {
+	text := strings.Builder{}
+	for _, span := range spans {
+		text.WriteString(span.Text)
+	}
+	return text.String()
+}
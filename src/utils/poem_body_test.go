@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseInlineMarkup(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		spans   []TextSpan
+		isValid bool
+	}{
+		{
+			name:    "plain text",
+			text:    "a verse",
+			spans:   []TextSpan{{Text: "a verse"}},
+			isValid: true,
+		},
+		{
+			name:    "italic span",
+			text:    "an *italic* word",
+			spans:   []TextSpan{{Text: "an "}, {Text: "italic", IsItalic: true}, {Text: " word"}},
+			isValid: true,
+		},
+		{
+			name:    "bold and italic span",
+			text:    "***a***",
+			spans:   []TextSpan{{Text: "a", IsItalic: true, IsBold: true}},
+			isValid: true,
+		},
+		{
+			name: "bold span nested in italic span",
+			text: "*a **b** c*",
+			spans: []TextSpan{
+				{Text: "a ", IsItalic: true},
+				{Text: "b", IsItalic: true, IsBold: true},
+				{Text: " c", IsItalic: true},
+			},
+			isValid: true,
+		},
+		{
+			name: "italic span closing with its bold span",
+			text: "**a *b***",
+			spans: []TextSpan{
+				{Text: "a ", IsBold: true},
+				{Text: "b", IsItalic: true, IsBold: true},
+			},
+			isValid: true,
+		},
+		{
+			name:    "unclosed italic span in bold span",
+			text:    "**a*",
+			isValid: false,
+		},
+		{
+			name:    "unclosed bold span",
+			text:    "**a",
+			isValid: false,
+		},
+		{
+			name:    "escaped asterisks",
+			text:    `\*a\*`,
+			spans:   []TextSpan{{Text: "*a*"}},
+			isValid: true,
+		},
+		{
+			name:    "escaped backslash",
+			text:    `a\\b`,
+			spans:   []TextSpan{{Text: `a\b`}},
+			isValid: true,
+		},
+		{
+			name:    "escaped backslash before a span",
+			text:    `\\*a*`,
+			spans:   []TextSpan{{Text: `\`}, {Text: "a", IsItalic: true}},
+			isValid: true,
+		},
+		{
+			name:    "lone backslash",
+			text:    `a\b`,
+			spans:   []TextSpan{{Text: `a\b`}},
+			isValid: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spans, err := ParseInlineMarkup(test.text)
+			if !test.isValid {
+				if err == nil {
+					t.Fatalf("ParseInlineMarkup(%q) = %v, want an error", test.text, spans)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInlineMarkup(%q) failed: %v", test.text, err)
+			}
+			if !reflect.DeepEqual(spans, test.spans) {
+				t.Errorf("ParseInlineMarkup(%q) = %v, want %v", test.text, spans, test.spans)
+			}
+		})
+	}
+}
+
+func TestPoemBodyVerses(t *testing.T) {
+	tests := []struct {
+		name   string
+		verses []string
+	}{
+		{
+			name:   "plain verses",
+			verses: []string{"a first verse", "a second verse"},
+		},
+		{
+			name:   "verses with asterisks",
+			verses: []string{"*not italic*", "**not bold**", "a * b"},
+		},
+		{
+			name:   "verses with backslashes",
+			verses: []string{`a\b`, `a\\b`, `\*`, `ends with \`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verses := NewPoemBody(test.verses).Verses()
+			if !reflect.DeepEqual(verses, test.verses) {
+				t.Errorf("NewPoemBody(%q).Verses() = %q", test.verses, verses)
+			}
+			// poems used to be stored as a flat list of verses
+			text, err := json.Marshal(test.verses)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ParsePoemBody(string(text))
+			if err != nil {
+				t.Fatalf("ParsePoemBody(%s) failed: %v", text, err)
+			}
+			if err = body.Validate(); err != nil {
+				t.Errorf("ParsePoemBody(%s) is invalid: %v", text, err)
+			}
+			if verses = body.Verses(); !reflect.DeepEqual(verses, test.verses) {
+				t.Errorf("ParsePoemBody(%s).Verses() = %q", text, verses)
+			}
+		})
+	}
+}
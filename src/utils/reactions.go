@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// The default reaction on poems, which likes made before reactions
+// existed were migrated to, so it is always available.
+const DefaultPoemReaction = "heart"
+
+var (
+	// The reactions available when no reactions have been configured.
+	DefaultPoemReactions = []string{DefaultPoemReaction, "moved", "inspired", "thoughtful"}
+)
+
+// Retrieves the reactions users can have on poems, configured through
+// a comma-separated APP_POEM_REACTIONS environment variable.
+// The default reaction always comes first, whether it is configured or not.
+func GetPoemReactions() []string {
+	reactions := []string{DefaultPoemReaction}
+	seen := map[string]bool{DefaultPoemReaction: true}
+	isConfigured := false
+	for _, name := range strings.Split(os.Getenv("APP_POEM_REACTIONS"), ",") {
+		reaction := strings.ToLower(strings.TrimSpace(name))
+		isConfigured = isConfigured || len(reaction) > 0
+		if len(reaction) > 0 && !seen[reaction] {
+			seen[reaction] = true
+			reactions = append(reactions, reaction)
+		}
+	}
+	if !isConfigured {
+		return DefaultPoemReactions
+	}
+	return reactions
+}
+
+// Retrieves the default reaction on poems.
+func GetDefaultPoemReaction() string {
+	return DefaultPoemReaction
+}
+
+// Checks if a reaction is one of the configured reactions.
+func IsValidPoemReaction(reaction string) bool {
+	for _, poemReaction := range GetPoemReactions() {
+		if poemReaction == reaction {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// The text search configuration used when none is given.
+	DefaultSearchLanguage = "english"
+	// The options of the snippets highlighting the matches of a search.
+	// The snippets are made of HTML-escaped text so that the marks
+	// around the matches are their only markup.
+	SearchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=24, MinWords=8"
+)
+
+var (
+	// The built-in Postgres text search configurations poems can be indexed with.
+	SearchLanguages = []string{
+		"simple", "arabic", "danish", "dutch", "english", "finnish", "french",
+		"german", "hungarian", "italian", "norwegian", "portuguese", "romanian",
+		"russian", "spanish", "swedish", "turkish",
+	}
+)
+
+// Resolves the text search configuration of a poem or search query,
+// which defaults to English.
+func GetSearchLanguage(language string) (string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if len(language) == 0 {
+		return DefaultSearchLanguage, nil
+	}
+	for _, searchLanguage := range SearchLanguages {
+		if searchLanguage == language {
+			return language, nil
+		}
+	}
+	return "", errors.New("Unsupported language.")
+}
+
+// Escapes the wildcards of a LIKE pattern.
+func EscapeLikePattern(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(text)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// The maximum length of a tag's name.
+	MaxTagLength = 64
+	// The maximum number of tags a poem can have.
+	MaxPoemTags = 16
+)
+
+var (
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#])#([\p{L}\p{N}_]+)`)
+	tagPattern     = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+)
+
+// Converts a tag's name into its stored form.
+// The second value is false if the name isn't a valid tag.
+func NormalizeTag(name string) (string, bool) {
+	tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	if len(tag) < 1 || utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", false
+	}
+	return tag, tagPattern.MatchString(tag)
+}
+
+// Retrieves the hashtags used in the given verses.
+func ExtractHashtags(verses []string) []string {
+	tags := []string{}
+	for _, verse := range verses {
+		for _, match := range hashtagPattern.FindAllStringSubmatch(verse, -1) {
+			tags = append(tags, match[1])
+		}
+	}
+	return tags
+}
+
+// Combines a poem's explicit tags and the hashtags in its verses
+// into a list of unique normalized tags. Invalid hashtags and those
+// beyond the maximum number of tags are left out, as they're part of
+// the verses rather than something the author chose as a tag.
+func GetPoemTags(tags []string, verses []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	poemTags := []string{}
+	for _, name := range tags {
+		tag, ok := NormalizeTag(name)
+		if !ok {
+			return nil, errors.New("Invalid tag.")
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			poemTags = append(poemTags, tag)
+		}
+	}
+	if len(poemTags) > MaxPoemTags {
+		return nil, errors.New("Too many tags.")
+	}
+	for _, name := range ExtractHashtags(verses) {
+		tag, ok := NormalizeTag(name)
+		if !ok || seen[tag] {
+			continue
+		}
+		if len(poemTags) >= MaxPoemTags {
+			break
+		}
+		seen[tag] = true
+		poemTags = append(poemTags, tag)
+	}
+	return poemTags, nil
+}
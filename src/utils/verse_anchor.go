@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Represents the position of an annotation within a poem's verses.
+// A RangeStart and RangeEnd of -1 anchor the annotation to the whole verse,
+// otherwise they are the rune offsets [RangeStart, RangeEnd) within it.
+type VerseAnchor struct {
+	VerseIndex int
+	RangeStart int
+	RangeEnd   int
+	Text       string
+	IsOrphaned bool
+}
+
+// Creates an anchor to a verse or a range of characters within a verse.
+func NewVerseAnchor(verses []string, verseIndex int, rangeStart, rangeEnd *int) (*VerseAnchor, error) {
+	if verseIndex < 0 || verseIndex >= len(verses) {
+		return nil, errors.New("Invalid verse index.")
+	}
+	verse := verses[verseIndex]
+	if rangeStart == nil && rangeEnd == nil {
+		return &VerseAnchor{
+			VerseIndex: verseIndex,
+			RangeStart: -1,
+			RangeEnd:   -1,
+			Text:       verse,
+		}, nil
+	}
+	if rangeStart == nil || rangeEnd == nil {
+		return nil, errors.New("Both ends of the range are needed.")
+	}
+	if *rangeStart < 0 || *rangeEnd <= *rangeStart || *rangeEnd > utf8.RuneCountInString(verse) {
+		return nil, errors.New("Invalid verse range.")
+	}
+	runes := []rune(verse)
+	return &VerseAnchor{
+		VerseIndex: verseIndex,
+		RangeStart: *rangeStart,
+		RangeEnd:   *rangeEnd,
+		Text:       string(runes[*rangeStart:*rangeEnd]),
+	}, nil
+}
+
+// Checks if an anchor covers a whole verse.
+func (anchor VerseAnchor) IsWholeVerse() bool {
+	return anchor.RangeStart < 0
+}
+
+// Moves an anchor from the old verses of a poem to its new verses.
+// Anchors whose text can no longer be found are marked as orphaned.
+func RemapVerseAnchor(oldVerses, newVerses []string, anchor VerseAnchor) VerseAnchor {
+	if !anchor.IsOrphaned {
+		for _, diff := range DiffVerses(oldVerses, newVerses) {
+			if diff.Op == VerseDiffEqual && diff.OldIndex == anchor.VerseIndex {
+				anchor.VerseIndex = diff.NewIndex
+				return anchor
+			}
+		}
+	}
+	// the verse changed, so look for the anchored text in the verse
+	// nearest to the anchor's previous position
+	bestIndex, bestStart := -1, -1
+	for i, verse := range newVerses {
+		start := -1
+		if anchor.IsWholeVerse() {
+			if verse == anchor.Text {
+				start = 0
+			}
+		} else if offset := strings.Index(verse, anchor.Text); offset >= 0 {
+			start = utf8.RuneCountInString(verse[:offset])
+		}
+		if start < 0 {
+			continue
+		}
+		if bestIndex < 0 || absInt(i-anchor.VerseIndex) < absInt(bestIndex-anchor.VerseIndex) {
+			bestIndex, bestStart = i, start
+		}
+	}
+	if bestIndex < 0 {
+		anchor.IsOrphaned = true
+		return anchor
+	}
+	anchor.VerseIndex = bestIndex
+	anchor.IsOrphaned = false
+	if !anchor.IsWholeVerse() {
+		anchor.RangeStart = bestStart
+		anchor.RangeEnd = bestStart + utf8.RuneCountInString(anchor.Text)
+	}
+	return anchor
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
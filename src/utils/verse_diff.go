@@ -0,0 +1,63 @@
+package utils
+
+const (
+	// A verse present in both versions of a poem.
+	VerseDiffEqual = "equal"
+	// A verse present only in the newer version of a poem.
+	VerseDiffInsert = "insert"
+	// A verse present only in the older version of a poem.
+	VerseDiffDelete = "delete"
+)
+
+// Represents a change to a single verse between two versions of a poem.
+type VerseDiff struct {
+	Op       string `json:"op"`
+	Verse    string `json:"verse"`
+	OldIndex int    `json:"oldIndex"`
+	NewIndex int    `json:"newIndex"`
+}
+
+// Computes the verse-level differences between two lists of verses.
+// Indices that do not apply to a change are set to -1.
+func DiffVerses(oldVerses, newVerses []string) []VerseDiff {
+	n, m := len(oldVerses), len(newVerses)
+	// lcs[i][j] holds the length of the longest common subsequence
+	// of oldVerses[i:] and newVerses[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldVerses[i] == newVerses[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	diffs := make([]VerseDiff, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		if oldVerses[i] == newVerses[j] {
+			diffs = append(diffs, VerseDiff{VerseDiffEqual, oldVerses[i], i, j})
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			diffs = append(diffs, VerseDiff{VerseDiffDelete, oldVerses[i], i, -1})
+			i++
+		} else {
+			diffs = append(diffs, VerseDiff{VerseDiffInsert, newVerses[j], -1, j})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		diffs = append(diffs, VerseDiff{VerseDiffDelete, oldVerses[i], i, -1})
+	}
+	for ; j < m; j++ {
+		diffs = append(diffs, VerseDiff{VerseDiffInsert, newVerses[j], -1, j})
+	}
+	return diffs
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The length in bytes of the secrets webhook payloads are signed with.
+const WebhookSecretLength = 32
+
+// Creates a random secret for signing a webhook's payloads.
+func GenerateWebhookSecret() (string, error) {
+	secret := make([]byte, WebhookSecretLength)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Creates the HMAC-SHA256 signature of a webhook payload sent at a given
+// Unix timestamp. Receivers can verify a delivery by computing the signature
+// of "<timestamp>.<payload>" with the webhook's secret.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checks if a URL can receive webhook deliveries. Its host must only
+// resolve to public addresses so that webhooks can't reach the server's
+// internal network.
+func ValidateWebhookUrl(webhookUrl string) error {
+	parsedUrl, err := url.Parse(webhookUrl)
+	if err != nil || len(parsedUrl.Hostname()) == 0 {
+		return errors.New("Invalid webhook URL.")
+	}
+	if parsedUrl.Scheme != "https" && parsedUrl.Scheme != "http" {
+		return errors.New("Webhook URL must use http or https.")
+	}
+	ips, err := net.LookupIP(parsedUrl.Hostname())
+	if err != nil || len(ips) == 0 {
+		return errors.New("Webhook URL's host could not be resolved.")
+	}
+	for _, ip := range ips {
+		if !IsPublicIP(ip) {
+			return errors.New("Webhook URL must not point to an internal address.")
+		}
+	}
+	return nil
+}
+
+// Checks if an IP address is reachable on the public internet rather than
+// being a loopback, private, link-local, multicast or unspecified address.
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
+
+// Checks if a user is an admin, configured through a
+// comma-separated APP_ADMIN_IDS environment variable.
+func IsAdmin(userId string) bool {
+	for _, adminId := range strings.Split(os.Getenv("APP_ADMIN_IDS"), ",") {
+		if len(userId) > 0 && strings.TrimSpace(adminId) == userId {
+			return true
+		}
+	}
+	return false
+}